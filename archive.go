@@ -0,0 +1,376 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// manifestFile is the name of an Archive's manifest, in its directory.
+const manifestFile = "manifest.json"
+
+// DefaultConcurrency is how many documents DownloadDocuments fetches at once
+// unless told otherwise.
+const DefaultConcurrency = 4
+
+// An Archive is a local store of Fund documents. Each document is stored
+// once under its SHA-256 digest, in objects/ab/abcdef….pdf, and a manifest
+// records which Fund and kind of document each came from. Documents
+// replaced upstream keep their old copies.
+//
+// An Archive is safe for concurrent use, but not by several processes.
+type Archive struct {
+	dir string
+
+	mu      sync.Mutex
+	entries map[manifestKey]ManifestEntry
+}
+
+// A ManifestEntry records a document in an Archive.
+type ManifestEntry struct {
+	FundID int          `json:"fund_id"`
+	Symbol string       `json:"symbol,omitempty"`
+	Kind   DocumentKind `json:"kind"`
+	URL    string       `json:"url"`
+	SHA256 string       `json:"sha256"`
+	Size   int64        `json:"size"`
+	// Path is the document's file, relative to the Archive's directory.
+	Path string `json:"path"`
+
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	FetchedAt    time.Time `json:"fetched_at"`
+}
+
+// A manifestKey identifies a ManifestEntry: each Fund has at most one
+// document of each kind.
+type manifestKey struct {
+	fundID int
+	kind   DocumentKind
+}
+
+// OpenArchive opens the Archive in dir, creating dir if needed.
+func OpenArchive(dir string) (*Archive, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("Opening archive failed: %w", err)
+	}
+	a := &Archive{dir: dir, entries: map[manifestKey]ManifestEntry{}}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, manifestFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return a, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Reading archive manifest failed: %w", err)
+	}
+	var m struct {
+		Entries []ManifestEntry `json:"entries"`
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("Reading archive manifest failed: %w", err)
+	}
+	for _, e := range m.Entries {
+		a.entries[manifestKey{e.FundID, e.Kind}] = e
+	}
+
+	return a, nil
+}
+
+// Dir returns the Archive's directory.
+func (a *Archive) Dir() string {
+	return a.dir
+}
+
+// Manifest returns the Archive's entries, sorted by Fund ID and kind.
+func (a *Archive) Manifest() []ManifestEntry {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	es := make([]ManifestEntry, 0, len(a.entries))
+	for _, e := range a.entries {
+		es = append(es, e)
+	}
+	sortEntries(es)
+
+	return es
+}
+
+// sortEntries sorts es by Fund ID and kind.
+func sortEntries(es []ManifestEntry) {
+	sort.SliceStable(es, func(i, j int) bool {
+		if es[i].FundID != es[j].FundID {
+			return es[i].FundID < es[j].FundID
+		}
+		return es[i].Kind < es[j].Kind
+	})
+}
+
+// Open opens e's document.
+func (a *Archive) Open(e ManifestEntry) (*os.File, error) {
+	return os.Open(filepath.Join(a.dir, filepath.FromSlash(e.Path)))
+}
+
+// save writes the Archive's manifest.
+func (a *Archive) save() error {
+	m := struct {
+		Entries []ManifestEntry `json:"entries"`
+	}{a.Manifest()}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(a.dir, manifestFile), data)
+}
+
+// archived returns an entry for the archived copy of url, and whether
+// there is one whose file is present.
+func (a *Archive) archived(url string) (ManifestEntry, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, e := range a.entries {
+		if e.URL != url {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(a.dir, filepath.FromSlash(e.Path))); err == nil {
+			return e, true
+		}
+	}
+	return ManifestEntry{}, false
+}
+
+// store writes body as an object, unless it is already there, and returns
+// its path relative to the Archive's directory.
+func (a *Archive) store(url string, body []byte, sum string) (string, error) {
+	ext := strings.ToLower(path.Ext(url))
+	if len(ext) > 8 || strings.ContainsAny(ext, "/?#") {
+		ext = ""
+	}
+	rel := path.Join("objects", sum[:2], sum+ext)
+	p := filepath.Join(a.dir, filepath.FromSlash(rel))
+	if _, err := os.Stat(p); err == nil {
+		return rel, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return "", err
+	}
+	return rel, writeFileAtomic(p, body)
+}
+
+// record adds e to the manifest, replacing the entry for the same Fund and
+// kind.
+func (a *Archive) record(e ManifestEntry) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.entries[manifestKey{e.FundID, e.Kind}] = e
+}
+
+// A DownloadOption configures DownloadDocuments.
+type DownloadOption func(*downloadOptions)
+
+type downloadOptions struct {
+	concurrency int
+	kinds       map[DocumentKind]bool
+}
+
+// WithConcurrency bounds how many documents are fetched at once. Values
+// below 1 mean DefaultConcurrency.
+func WithConcurrency(n int) DownloadOption {
+	return func(o *downloadOptions) {
+		o.concurrency = n
+	}
+}
+
+// WithDocumentKinds limits a download to documents of the given kinds.
+func WithDocumentKinds(kinds ...DocumentKind) DownloadOption {
+	return func(o *downloadOptions) {
+		o.kinds = map[DocumentKind]bool{}
+		for _, k := range kinds {
+			o.kinds[k] = true
+		}
+	}
+}
+
+// A DownloadResult reports what DownloadDocuments did with each document.
+type DownloadResult struct {
+	// Downloaded lists documents that were new or had changed.
+	Downloaded []ManifestEntry
+	// Unchanged lists documents the Archive already held.
+	Unchanged []ManifestEntry
+	// Failed lists documents that could not be archived.
+	Failed []*DownloadError
+}
+
+// A DownloadError reports a document that could not be archived.
+type DownloadError struct {
+	FundID int
+	Symbol string
+	Kind   DocumentKind
+	URL    string
+	Err    error
+}
+
+func (e *DownloadError) Error() string {
+	return fmt.Sprintf("Downloading %s of fund %d (%s) failed: %v", e.Kind, e.FundID, e.Symbol, e.Err)
+}
+
+func (e *DownloadError) Unwrap() error {
+	return e.Err
+}
+
+// DownloadDocuments fetches every document linked from funds into a,
+// skipping documents it already holds. A link shared by several Funds is
+// fetched once. Requests go through the Client's HTTP client, timeout and
+// RetryPolicy, and are made conditional on the validators of an archived
+// copy, so unchanged documents are not downloaded again.
+//
+// A document that cannot be fetched or stored is reported in the result's
+// Failed list without stopping the others. The returned error is non-nil
+// only if the manifest cannot be saved, or, as a *ContextError, if ctx is
+// done first; documents archived by then are still recorded.
+func (c *Client) DownloadDocuments(ctx context.Context, a *Archive, funds []Fund, opts ...DownloadOption) (DownloadResult, error) {
+	o := downloadOptions{concurrency: DefaultConcurrency}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.concurrency < 1 {
+		o.concurrency = DefaultConcurrency
+	}
+
+	var res DownloadResult
+	byURL := map[string][]ManifestEntry{}
+	var urls []string
+	for _, f := range funds {
+		for _, d := range f.Documents() {
+			if o.kinds != nil && !o.kinds[d.Kind] {
+				continue
+			}
+			e := ManifestEntry{FundID: f.ID, Symbol: f.Symbol, Kind: d.Kind, URL: d.Raw}
+			if d.Err != nil {
+				res.Failed = append(res.Failed, downloadError(e, d.Err))
+				continue
+			}
+			if byURL[d.Raw] == nil {
+				urls = append(urls, d.Raw)
+			}
+			byURL[d.Raw] = append(byURL[d.Raw], e)
+		}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, o.concurrency)
+	for _, url := range urls {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(url string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			entries, changed, err := c.archiveDocument(ctx, a, url, byURL[url])
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err != nil:
+				for _, e := range entries {
+					res.Failed = append(res.Failed, downloadError(e, err))
+				}
+			case changed:
+				res.Downloaded = append(res.Downloaded, entries...)
+			default:
+				res.Unchanged = append(res.Unchanged, entries...)
+			}
+		}(url)
+	}
+	wg.Wait()
+	sortEntries(res.Downloaded)
+	sortEntries(res.Unchanged)
+	sort.SliceStable(res.Failed, func(i, j int) bool {
+		return res.Failed[i].FundID < res.Failed[j].FundID ||
+			res.Failed[i].FundID == res.Failed[j].FundID && res.Failed[i].Kind < res.Failed[j].Kind
+	})
+
+	if err := a.save(); err != nil {
+		return res, fmt.Errorf("Saving archive manifest failed: %w", err)
+	}
+	if ctx.Err() != nil {
+		return res, contextErr(ctx, ctx.Err())
+	}
+
+	return res, nil
+}
+
+// archiveDocument fetches url into a and records it for entries. It reports
+// whether the archive's copy changed.
+func (c *Client) archiveDocument(ctx context.Context, a *Archive, url string, entries []ManifestEntry) ([]ManifestEntry, bool, error) {
+	prev, ok := a.archived(url)
+	var cached *cacheEntry
+	if ok {
+		cached = &cacheEntry{URL: url, ETag: prev.ETag, LastModified: prev.LastModified}
+	}
+	resp, err := c.get(ctx, url, "*/*", cached)
+	if err != nil {
+		return entries, false, err
+	}
+
+	stored, changed := prev, false
+	if !resp.notModified {
+		sum := sha256Hex(resp.body)
+		rel, err := a.store(url, resp.body, sum)
+		if err != nil {
+			return entries, false, err
+		}
+		stored = ManifestEntry{SHA256: sum, Size: int64(len(resp.body)), Path: rel}
+		changed = !ok || prev.SHA256 != sum
+	}
+	if resp.etag != "" || !resp.notModified {
+		stored.ETag = resp.etag
+	}
+	if resp.lastModified != "" || !resp.notModified {
+		stored.LastModified = resp.lastModified
+	}
+
+	now := c.now()
+	out := make([]ManifestEntry, len(entries))
+	for i, e := range entries {
+		e.SHA256, e.Size, e.Path = stored.SHA256, stored.Size, stored.Path
+		e.ETag, e.LastModified, e.FetchedAt = stored.ETag, stored.LastModified, now
+		a.record(e)
+		out[i] = e
+	}
+
+	return out, changed, nil
+}
+
+func downloadError(e ManifestEntry, err error) *DownloadError {
+	return &DownloadError{FundID: e.FundID, Symbol: e.Symbol, Kind: e.Kind, URL: e.URL, Err: err}
+}
@@ -0,0 +1,249 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// docServer serves documents from a map of paths to contents, with ETags,
+// counting full responses and tracking peak concurrency.
+type docServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	docs     map[string]string
+	served   map[string]int
+	inFlight int32
+	peak     int32
+}
+
+func newDocServer(t *testing.T, docs map[string]string) *docServer {
+	t.Helper()
+	ds := &docServer{docs: docs, served: map[string]int{}}
+	ds.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&ds.inFlight, 1)
+		defer atomic.AddInt32(&ds.inFlight, -1)
+		for {
+			peak := atomic.LoadInt32(&ds.peak)
+			if n <= peak || atomic.CompareAndSwapInt32(&ds.peak, peak, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+
+		ds.mu.Lock()
+		body, ok := ds.docs[r.URL.Path]
+		if ok && r.Header.Get("If-None-Match") != `"`+sha256Hex([]byte(body))+`"` {
+			ds.served[r.URL.Path]++
+		}
+		ds.mu.Unlock()
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		etag := `"` + sha256Hex([]byte(body)) + `"`
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(ds.Close)
+	return ds
+}
+
+func (ds *docServer) set(path, body string) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	ds.docs[path] = body
+}
+
+func (ds *docServer) servedCount(path string) int {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	return ds.served[path]
+}
+
+func TestDownloadDocuments(t *testing.T) {
+	ds := newDocServer(t, map[string]string{
+		"/GUT12312020.pdf":    "GUT annual",
+		"/GGT12312020.pdf":    "GGT annual",
+		"/-113.pdf":           "GUT prospectus",
+		"/WEB_CEF_4Q2020.pdf": "commentary",
+		"/GUT06302020.pdf":    "GUT semi-annual",
+		"/GGT06302020.pdf":    "GGT semi-annual",
+		"/2006q3/-112.pdf":    "GGT quarterly",
+		"/GGTFacts4Q2020.pdf": "GGT factsheet",
+		"/GUTFacts4Q2020.pdf": "GUT factsheet",
+	})
+	funds := []Fund{
+		{
+			ID: 515, Symbol: "GUT",
+			AnnualReport:     ds.URL + "/GUT12312020.pdf",
+			SemiAnnualReport: ds.URL + "/GUT06302020.pdf",
+			Prospectus:       ds.URL + "/-113.pdf",
+			Factsheet:        ds.URL + "/GUTFacts4Q2020.pdf",
+			Commentary:       ds.URL + "/WEB_CEF_4Q2020.pdf",
+		},
+		{
+			ID: 504, Symbol: "GGT",
+			AnnualReport:     ds.URL + "/GGT12312020.pdf",
+			SemiAnnualReport: ds.URL + "/GGT06302020.pdf",
+			QuarterlyReport:  ds.URL + "/2006q3/-112.pdf",
+			Factsheet:        ds.URL + "/GGTFacts4Q2020.pdf",
+			Commentary:       ds.URL + "/WEB_CEF_4Q2020.pdf",
+		},
+		{
+			ID: 516, Symbol: "GUT",
+			Prospectus: ds.URL + "/-113.pdf",
+			Sai:        ds.URL + "/missing_sai.pdf",
+			Soi:        "not a link",
+		},
+	}
+	dir := t.TempDir()
+	c := NewClient(WithRetryPolicy(NoRetry))
+
+	a, err := OpenArchive(dir)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	res, err := c.DownloadDocuments(context.Background(), a, funds, WithConcurrency(2))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if len(res.Downloaded) != 11 || len(res.Unchanged) != 0 || len(res.Failed) != 2 {
+		t.Fatalf("got %v downloaded, %v unchanged, %v failed, want 11, 0, 2", len(res.Downloaded), len(res.Unchanged), len(res.Failed))
+	}
+	if peak := atomic.LoadInt32(&ds.peak); peak > 2 {
+		t.Errorf("got %v concurrent requests, want at most 2", peak)
+	}
+	for _, path := range []string{"/WEB_CEF_4Q2020.pdf", "/-113.pdf"} {
+		if got := ds.servedCount(path); got != 1 {
+			t.Errorf("%s: got %v requests, want 1 for a shared link", path, got)
+		}
+	}
+
+	var apiErr *APIError
+	if f := res.Failed[0]; f.FundID != 516 || f.Kind != SAIDoc || !errors.As(f, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("got %v, want 404 for fund 516's SAI", f)
+	}
+	if f := res.Failed[1]; f.FundID != 516 || f.Kind != SOIDoc {
+		t.Errorf("got %v, want invalid link for fund 516's SOI", f)
+	}
+
+	e := res.Downloaded[0]
+	if e.FundID != 504 || e.Kind != AnnualReportDoc || e.SHA256 != sha256Hex([]byte("GGT annual")) || e.Size != 10 {
+		t.Errorf("got %+v, want GGT's annual report", e)
+	}
+	if want := filepath.ToSlash(filepath.Join("objects", e.SHA256[:2], e.SHA256+".pdf")); e.Path != want {
+		t.Errorf("got path %q, want %q", e.Path, want)
+	}
+	f, err := a.Open(e)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	body, _ := ioutil.ReadAll(f)
+	f.Close()
+	if string(body) != "GGT annual" {
+		t.Errorf("got %q, want the archived document", body)
+	}
+
+	// A fresh process sees the saved manifest, and revalidates instead of
+	// downloading again.
+	ds.set("/GUT12312020.pdf", "GUT annual, restated")
+	a, err = OpenArchive(dir)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if got := len(a.Manifest()); got != 11 {
+		t.Fatalf("got %v manifest entries, want 11", got)
+	}
+	res, err = c.DownloadDocuments(context.Background(), a, funds)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if len(res.Downloaded) != 1 || len(res.Unchanged) != 10 {
+		t.Fatalf("got %v downloaded, %v unchanged, want 1, 10", len(res.Downloaded), len(res.Unchanged))
+	}
+	if got := ds.servedCount("/GGT12312020.pdf"); got != 1 {
+		t.Errorf("got %v full responses for an unchanged document, want 1", got)
+	}
+	restated := res.Downloaded[0]
+	if restated.FundID != 515 || restated.SHA256 != sha256Hex([]byte("GUT annual, restated")) {
+		t.Errorf("got %+v, want GUT's restated annual report", restated)
+	}
+	old := sha256Hex([]byte("GUT annual"))
+	if _, err := a.Open(ManifestEntry{Path: "objects/" + old[:2] + "/" + old + ".pdf"}); err != nil {
+		t.Errorf("got %v, want the replaced document kept", err)
+	}
+	if unchanged := res.Unchanged[0]; unchanged.SHA256 == "" || unchanged.Path == "" || unchanged.Size == 0 {
+		t.Errorf("got %+v, want archived copy's details", unchanged)
+	}
+}
+
+func TestDownloadDocumentsKinds(t *testing.T) {
+	ds := newDocServer(t, map[string]string{"/a.pdf": "annual", "/p.pdf": "prospectus"})
+	funds := []Fund{{ID: 1, AnnualReport: ds.URL + "/a.pdf", Prospectus: ds.URL + "/p.pdf"}}
+	a, err := OpenArchive(t.TempDir())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	res, err := NewClient().DownloadDocuments(context.Background(), a, funds, WithDocumentKinds(ProspectusDoc))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if len(res.Downloaded) != 1 || res.Downloaded[0].Kind != ProspectusDoc {
+		t.Errorf("got %+v, want only the prospectus", res.Downloaded)
+	}
+}
+
+func TestDownloadDocumentsCanceled(t *testing.T) {
+	ds := newDocServer(t, map[string]string{"/a.pdf": "annual"})
+	funds := []Fund{{ID: 1, AnnualReport: ds.URL + "/a.pdf"}}
+	a, err := OpenArchive(t.TempDir())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = NewClient().DownloadDocuments(ctx, a, funds)
+	var ctxErr *ContextError
+	if !errors.As(err, &ctxErr) {
+		t.Errorf("got %v, want *ContextError", err)
+	}
+}
+
+func TestOpenArchiveBadManifest(t *testing.T) {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, manifestFile), []byte("{"), 0o644); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if _, err := OpenArchive(dir); err == nil {
+		t.Errorf("got nil error, want unreadable manifest")
+	}
+}
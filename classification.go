@@ -0,0 +1,128 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import "strings"
+
+// An AssetType is a Fund's asset class. Known spellings, including the
+// API's stray whitespace and typos, decode to the constants below; any other
+// value, null and "" decode to UnknownAssetType. Fund keeps the value as sent
+// in RawAssetType.
+type AssetType string
+
+// Known asset types, and UnknownAssetType for the rest.
+const (
+	Equity           AssetType = "Equity"
+	ConvertibleBond  AssetType = "Convertible Bond"
+	UnknownAssetType AssetType = "Unknown"
+)
+
+var assetTypeAliases = map[string]AssetType{
+	"equity":           Equity,
+	"convertible bond": ConvertibleBond,
+	"covertible bond":  ConvertibleBond,
+	"convertible":      ConvertibleBond,
+}
+
+// ParseAssetType normalizes raw to a known AssetType, or returns
+// UnknownAssetType if it matches none.
+func ParseAssetType(raw string) AssetType {
+	if a, ok := assetTypeAliases[normalizeKey(raw)]; ok {
+		return a
+	}
+	return UnknownAssetType
+}
+
+// Known reports whether a is one of the known asset types.
+func (a AssetType) Known() bool {
+	switch a {
+	case Equity, ConvertibleBond:
+		return true
+	}
+	return false
+}
+
+// String returns a as a string.
+func (a AssetType) String() string { return string(a) }
+
+// MarshalText implements encoding.TextMarshaler.
+func (a AssetType) MarshalText() ([]byte, error) { return []byte(a), nil }
+
+// UnmarshalText implements encoding.TextUnmarshaler, normalizing the text
+// with ParseAssetType.
+func (a *AssetType) UnmarshalText(text []byte) error {
+	*a = ParseAssetType(string(text))
+	return nil
+}
+
+// A Category is a Fund's investment category. Like AssetType, known
+// spellings decode to the constants below and anything else to
+// UnknownCategory. Fund keeps the value as sent in RawCategory.
+type Category string
+
+// Known categories, spelled as the API sends them, and UnknownCategory for
+// the rest.
+const (
+	Value           Category = "value"
+	MergerArbitrage Category = "merger arbitrage"
+	EquityOption    Category = "equity option funds"
+	UnknownCategory Category = "unknown"
+)
+
+var categoryAliases = map[string]Category{
+	"value":               Value,
+	"merger arbitrage":    MergerArbitrage,
+	"merger arb":          MergerArbitrage,
+	"equity option funds": EquityOption,
+	"equity option fund":  EquityOption,
+	"equity option":       EquityOption,
+}
+
+// ParseCategory normalizes raw to a known Category, or returns
+// UnknownCategory if it matches none.
+func ParseCategory(raw string) Category {
+	if c, ok := categoryAliases[normalizeKey(raw)]; ok {
+		return c
+	}
+	return UnknownCategory
+}
+
+// Known reports whether c is one of the known categories.
+func (c Category) Known() bool {
+	switch c {
+	case Value, MergerArbitrage, EquityOption:
+		return true
+	}
+	return false
+}
+
+// String returns c as a string.
+func (c Category) String() string { return string(c) }
+
+// MarshalText implements encoding.TextMarshaler.
+func (c Category) MarshalText() ([]byte, error) { return []byte(c), nil }
+
+// UnmarshalText implements encoding.TextUnmarshaler, normalizing the text
+// with ParseCategory.
+func (c *Category) UnmarshalText(text []byte) error {
+	*c = ParseCategory(string(text))
+	return nil
+}
+
+// normalizeKey lowercases s and collapses its whitespace, for matching
+// against an alias table.
+func normalizeKey(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
@@ -0,0 +1,226 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jidicula/go-gamco/gamcotest"
+)
+
+func TestParseAssetType(t *testing.T) {
+	tests := map[string]struct {
+		in        string
+		want      AssetType
+		wantKnown bool
+	}{
+		"equity":            {in: "Equity", want: Equity, wantKnown: true},
+		"trailing space":    {in: "Equity ", want: Equity, wantKnown: true},
+		"lowercase":         {in: "equity", want: Equity, wantKnown: true},
+		"convertible":       {in: "Convertible Bond", want: ConvertibleBond, wantKnown: true},
+		"typo":              {in: "Covertible Bond", want: ConvertibleBond, wantKnown: true},
+		"doubled space":     {in: "Convertible  Bond", want: ConvertibleBond, wantKnown: true},
+		"unknown":           {in: "Municipal Bond", want: UnknownAssetType},
+		"unknown untrimmed": {in: " Municipal Bond ", want: UnknownAssetType},
+		"empty":             {in: "", want: UnknownAssetType},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := ParseAssetType(tt.in)
+			if got != tt.want {
+				t.Errorf("%s: got %q, want %q", name, got, tt.want)
+			}
+			if got.Known() != tt.wantKnown {
+				t.Errorf("%s: got Known() %v, want %v", name, got.Known(), tt.wantKnown)
+			}
+		})
+	}
+}
+
+func TestParseCategory(t *testing.T) {
+	tests := map[string]struct {
+		in        string
+		want      Category
+		wantKnown bool
+	}{
+		"value":         {in: "value", want: Value, wantKnown: true},
+		"capitalized":   {in: "Value", want: Value, wantKnown: true},
+		"merger":        {in: "merger arbitrage", want: MergerArbitrage, wantKnown: true},
+		"equity option": {in: "equity option funds", want: EquityOption, wantKnown: true},
+		"singular":      {in: "Equity Option Fund", want: EquityOption, wantKnown: true},
+		"unknown":       {in: "Growth", want: UnknownCategory},
+		"empty":         {in: "", want: UnknownCategory},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := ParseCategory(tt.in)
+			if got != tt.want {
+				t.Errorf("%s: got %q, want %q", name, got, tt.want)
+			}
+			if got.Known() != tt.wantKnown {
+				t.Errorf("%s: got Known() %v, want %v", name, got.Known(), tt.wantKnown)
+			}
+		})
+	}
+}
+
+func TestClassificationExample(t *testing.T) {
+	fl, err := decodeFunds(gamcotest.Example())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	assetTypes := map[AssetType]int{}
+	categories := map[Category]int{}
+	rawAssetTypes := map[string]int{}
+	for _, f := range fl {
+		assetTypes[f.AssetType]++
+		categories[f.Category]++
+		rawAssetTypes[f.RawAssetType]++
+	}
+	wantRaw := map[string]int{"Equity": 43, "Equity ": 2, "Convertible Bond": 4, "Covertible Bond": 1, "": 3}
+	if !reflect.DeepEqual(rawAssetTypes, wantRaw) {
+		t.Errorf("got raw asset types %v, want %v", rawAssetTypes, wantRaw)
+	}
+	wantAssetTypes := map[AssetType]int{Equity: 45, ConvertibleBond: 5, UnknownAssetType: 3}
+	wantCategories := map[Category]int{Value: 42, EquityOption: 6, MergerArbitrage: 5}
+	if !reflect.DeepEqual(assetTypes, wantAssetTypes) {
+		t.Errorf("got asset types %v, want %v", assetTypes, wantAssetTypes)
+	}
+	if !reflect.DeepEqual(categories, wantCategories) {
+		t.Errorf("got categories %v, want %v", categories, wantCategories)
+	}
+}
+
+func TestAssetTypeJSON(t *testing.T) {
+	var got struct {
+		AssetType AssetType
+		Category  Category
+	}
+	if err := json.Unmarshal([]byte(`{"AssetType": "Covertible Bond", "Category": "Merger Arbitrage"}`), &got); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if got.AssetType != ConvertibleBond || got.Category != MergerArbitrage {
+		t.Errorf("got %+v, want normalized values", got)
+	}
+	b, err := json.Marshal(got)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if want := `{"AssetType":"Convertible Bond","Category":"merger arbitrage"}`; string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+}
+
+func TestFundUnknownClassification(t *testing.T) {
+	tests := map[string]struct {
+		data             string
+		wantRawAssetType string
+		wantRawCategory  string
+		wantJSON         []string
+	}{
+		"unknown": {
+			data:             `{"asset_type": "Municipal Bond", "category": "Growth "}`,
+			wantRawAssetType: "Municipal Bond",
+			wantRawCategory:  "Growth ",
+			wantJSON:         []string{`"asset_type":"Municipal Bond"`, `"category":"Growth "`},
+		},
+		"null": {
+			data:     `{"asset_type": null, "category": null}`,
+			wantJSON: []string{`"asset_type":null`, `"category":null`},
+		},
+		"empty": {
+			data:     `{"asset_type": "", "category": ""}`,
+			wantJSON: []string{`"asset_type":null`, `"category":null`},
+		},
+		"missing": {
+			data:     `{}`,
+			wantJSON: []string{`"asset_type":null`, `"category":null`},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var f Fund
+			if err := json.Unmarshal([]byte(tt.data), &f); err != nil {
+				t.Fatalf(err.Error())
+			}
+			if f.AssetType != UnknownAssetType || f.Category != UnknownCategory {
+				t.Errorf("%s: got %q and %q, want %q and %q", name, f.AssetType, f.Category, UnknownAssetType, UnknownCategory)
+			}
+			if f.RawAssetType != tt.wantRawAssetType || f.RawCategory != tt.wantRawCategory {
+				t.Errorf("%s: got raw %q and %q, want %q and %q", name, f.RawAssetType, f.RawCategory, tt.wantRawAssetType, tt.wantRawCategory)
+			}
+			b, err := json.Marshal(f)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			for _, want := range tt.wantJSON {
+				if !strings.Contains(string(b), want) {
+					t.Errorf("%s: got %s, want it to contain %s", name, b, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFundMarshalRawClassification(t *testing.T) {
+	var f Fund
+	if err := json.Unmarshal([]byte(`{"asset_type": "Covertible Bond", "category": " Value"}`), &f); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if f.AssetType != ConvertibleBond || f.RawAssetType != "Covertible Bond" {
+		t.Errorf("got %q (raw %q), want %q (raw %q)", f.AssetType, f.RawAssetType, ConvertibleBond, "Covertible Bond")
+	}
+
+	tests := map[string]struct {
+		edit func(*Fund)
+		want []string
+	}{
+		"as sent": {
+			edit: func(*Fund) {},
+			want: []string{`"asset_type":"Covertible Bond"`, `"category":" Value"`},
+		},
+		"changed": {
+			edit: func(f *Fund) { f.AssetType, f.Category = Equity, MergerArbitrage },
+			want: []string{`"asset_type":"Equity"`, `"category":"merger arbitrage"`},
+		},
+		"no raw value": {
+			edit: func(f *Fund) { f.RawAssetType, f.RawCategory = "", "" },
+			want: []string{`"asset_type":"Convertible Bond"`, `"category":"value"`},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			g := f
+			tt.edit(&g)
+			b, err := json.Marshal(g)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(b), want) {
+					t.Errorf("%s: got %s, want it to contain %s", name, b, want)
+				}
+			}
+		})
+	}
+}
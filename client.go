@@ -0,0 +1,275 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultBaseURL is the root of GAMCO's public API.
+	DefaultBaseURL = "https://gabdotcom-api.com/api/v1"
+
+	// DefaultUserAgent is the User-Agent header sent when none is configured.
+	DefaultUserAgent = "go-gamco"
+
+	// DefaultTimeout bounds each attempt to call the API.
+	DefaultTimeout = 30 * time.Second
+
+	// navClosedEndsPath is the endpoint serving every closed-end fund.
+	navClosedEndsPath = "/nav_closed_ends"
+)
+
+// defaultClient backs the package-level functions.
+var defaultClient = NewClient()
+
+// A Client fetches fund data from the GAMCO API, or from another
+// DataSource. Its zero value is not usable; create one with NewClient.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	userAgent  string
+	timeout    time.Duration
+	retry      RetryPolicy
+	cacheTTL   time.Duration
+	diskCache  *diskCache
+	source     DataSource
+	duplicates DuplicatePolicy
+	strict     bool
+	now        func() time.Time
+
+	mu       sync.Mutex
+	snapshot *Snapshot
+	inflight *flight
+	// generation counts Invalidate calls, so a fetch that was running
+	// during one does not cache its result.
+	generation uint64
+}
+
+// An Option configures a Client.
+type Option func(*Client)
+
+// WithBaseURL points the Client at baseURL instead of DefaultBaseURL, e.g. for
+// an internal mirror of the API.
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// WithHTTPClient makes the Client send its requests through hc.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithTimeout bounds each attempt to call the API by d. An attempt that runs
+// out of time fails like any other transport error, and is retried. A
+// non-positive d disables the Client's own timeout, leaving only the HTTP
+// client's.
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = d
+	}
+}
+
+// NewClient returns a Client configured by opts.
+func NewClient(opts ...Option) *Client {
+	c := &Client{
+		baseURL:    DefaultBaseURL,
+		httpClient: &http.Client{},
+		userAgent:  DefaultUserAgent,
+		timeout:    DefaultTimeout,
+		retry:      DefaultRetryPolicy,
+		now:        time.Now,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.httpClient == nil {
+		c.httpClient = &http.Client{}
+	}
+	if c.source == nil {
+		c.source = &httpSource{c: c}
+	}
+
+	return c
+}
+
+// getData hits the nav_closed_ends endpoint, retrying transient failures
+// according to the Client's RetryPolicy, and returns the last attempt. If
+// cached is non-nil, the request is made conditional on its validators.
+func (c *Client) getData(ctx context.Context, cached *cacheEntry) (fetchAttempt, error) {
+	return c.get(ctx, c.baseURL+navClosedEndsPath, "application/json", cached)
+}
+
+// get fetches url as getData does the nav_closed_ends endpoint, asking for
+// the accept media type.
+func (c *Client) get(ctx context.Context, url, accept string, cached *cacheEntry) (fetchAttempt, error) {
+	var a fetchAttempt
+	var err error
+
+	attempts := c.retry.attempts()
+	for attempt := 1; ; attempt++ {
+		a, err = c.fetch(ctx, url, accept, cached)
+		if err == nil {
+			return a, nil
+		}
+		if !a.retryable || attempt >= attempts || ctx.Err() != nil {
+			break
+		}
+
+		wait := c.retry.backoff(attempt, parseRetryAfter(a.retryAfter, time.Now()))
+		if sleepErr := sleep(ctx, wait); sleepErr != nil {
+			return a, contextErr(ctx, err)
+		}
+	}
+
+	return a, err
+}
+
+// A fetchAttempt is the outcome of a single request to the API.
+type fetchAttempt struct {
+	body []byte
+
+	// notModified reports a 304 answer to a conditional request; body is
+	// then empty.
+	notModified bool
+
+	// etag and lastModified are the response's cache validators.
+	etag         string
+	lastModified string
+
+	// retryable reports whether the failure was transient.
+	retryable bool
+
+	// retryAfter is the raw Retry-After header of a failed response.
+	retryAfter string
+}
+
+// fetch makes a single request for url, conditional on cached's validators
+// if it is non-nil. Only ctx being done yields a *ContextError; the attempt
+// exceeding the Client's timeout is a retryable transport error.
+func (c *Client) fetch(ctx context.Context, url, accept string, cached *cacheEntry) (fetchAttempt, error) {
+	var a fetchAttempt
+
+	attemptCtx := ctx
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		attemptCtx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(attemptCtx, http.MethodGet, url, nil)
+	if err != nil {
+		return a, fmt.Errorf("Request creation failed: %w", err)
+	}
+	req.Header.Set("Accept", accept)
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		a.retryable = idempotentMethod(req.Method)
+		return a, contextErr(ctx, c.attemptErr(attemptCtx, "HTTP GET failed", err))
+	}
+	defer resp.Body.Close()
+
+	a.etag = resp.Header.Get("ETag")
+	a.lastModified = resp.Header.Get("Last-Modified")
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		a.notModified = true
+		return a, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		a.retryable = idempotentMethod(req.Method) && retryableStatus(resp.StatusCode)
+		a.retryAfter = resp.Header.Get("Retry-After")
+		excerpt, _ := ioutil.ReadAll(io.LimitReader(resp.Body, apiErrorBodyLimit))
+		return a, &APIError{StatusCode: resp.StatusCode, Header: resp.Header, Body: excerpt}
+	}
+
+	a.body, err = ioutil.ReadAll(&contextReader{ctx: attemptCtx, r: resp.Body})
+	if err != nil {
+		a.retryable = idempotentMethod(req.Method)
+		return a, contextErr(ctx, c.attemptErr(attemptCtx, "Response decoding failed", err))
+	}
+
+	return a, nil
+}
+
+// attemptErr wraps err, which failed an attempt, under msg, saying so if
+// the attempt ran out of time.
+func (c *Client) attemptErr(attemptCtx context.Context, msg string, err error) error {
+	if attemptCtx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("%s: attempt timed out after %v: %w", msg, c.timeout, err)
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+// GetFund returns the symbol's matching Fund.
+func (c *Client) GetFund(symbol string) (Fund, error) {
+	return c.GetFundContext(context.Background(), symbol)
+}
+
+// GetFundContext returns the symbol's matching Fund, or a *NotFoundError
+// if there is none. Of several Funds sharing the symbol, it returns the one
+// picked by the Client's DuplicatePolicy. If ctx is canceled or its deadline
+// passes before the Fund is decoded, the returned error is a *ContextError.
+func (c *Client) GetFundContext(ctx context.Context, symbol string) (Fund, error) {
+	return c.getFundBy(ctx, "symbol", symbol, (*Snapshot).FundRecords)
+}
+
+// GetCommonFundList returns a list of common GAMCO Funds.
+func (c *Client) GetCommonFundList() ([]Fund, error) {
+	return c.GetCommonFundListContext(context.Background())
+}
+
+// GetCommonFundListContext returns a list of common GAMCO Funds. If ctx is
+// canceled or its deadline passes before the list is decoded, the returned
+// error is a *ContextError.
+func (c *Client) GetCommonFundListContext(ctx context.Context) ([]Fund, error) {
+	s, err := c.SnapshotContext(ctx)
+	if err != nil {
+		return []Fund{}, err
+	}
+
+	return s.CommonFunds(), nil
+}
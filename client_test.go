@@ -0,0 +1,231 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jidicula/go-gamco/gamcotest"
+)
+
+// newTestServer starts a fake GAMCO API that is closed when the test ends.
+func newTestServer(t *testing.T, opts ...gamcotest.Option) *gamcotest.Server {
+	t.Helper()
+	srv := gamcotest.NewServer(opts...)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestNewClient(t *testing.T) {
+	type config struct {
+		baseURL   string
+		userAgent string
+		timeout   time.Duration
+	}
+	hc := &http.Client{}
+	tests := map[string]struct {
+		opts     []Option
+		want     config
+		wantHTTP *http.Client
+	}{
+		"defaults": {
+			opts: nil,
+			want: config{baseURL: DefaultBaseURL, userAgent: DefaultUserAgent, timeout: DefaultTimeout},
+		},
+		"options": {
+			opts: []Option{
+				WithBaseURL("https://mirror.example.com/api/v1/"),
+				WithHTTPClient(hc),
+				WithUserAgent("reports/1.0"),
+				WithTimeout(time.Second),
+			},
+			want:     config{baseURL: "https://mirror.example.com/api/v1", userAgent: "reports/1.0", timeout: time.Second},
+			wantHTTP: hc,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := NewClient(tt.opts...)
+			if c.httpClient == nil {
+				t.Fatalf("%s: got nil http.Client", name)
+			}
+			if tt.wantHTTP != nil && c.httpClient != tt.wantHTTP {
+				t.Errorf("%s: got http.Client %p, want %p", name, c.httpClient, tt.wantHTTP)
+			}
+			got := config{baseURL: c.baseURL, userAgent: c.userAgent, timeout: c.timeout}
+			if got != tt.want {
+				t.Errorf("%s: got %+v, want %+v", name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientUserAgent(t *testing.T) {
+	srv := newTestServer(t)
+
+	want := "reports/1.0"
+	c := NewClient(WithBaseURL(srv.BaseURL()), WithUserAgent(want))
+	if _, err := c.getData(context.Background(), nil); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if got := srv.Requests()[0].Header.Get("User-Agent"); got != want {
+		t.Errorf("got User-Agent %q, want %q", got, want)
+	}
+}
+
+func TestClientTimeout(t *testing.T) {
+	srv := newTestServer(t, gamcotest.WithFaults(gamcotest.Latency(time.Minute)))
+
+	c := NewClient(WithBaseURL(srv.BaseURL()), WithTimeout(10*time.Millisecond), WithRetryPolicy(NoRetry))
+	_, err := c.getData(context.Background(), nil)
+	if err == nil {
+		t.Fatal("got nil error, want timeout")
+	}
+	var ctxErr *ContextError
+	if errors.As(err, &ctxErr) {
+		t.Errorf("got %v, want a transport error rather than *ContextError", err)
+	}
+}
+
+func TestClientTimeoutRetried(t *testing.T) {
+	srv := newTestServer(t, gamcotest.WithFaults(gamcotest.Latency(time.Minute)))
+
+	c := NewClient(
+		WithBaseURL(srv.BaseURL()),
+		WithTimeout(50*time.Millisecond),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}),
+	)
+	if _, err := c.GetFund("GUT"); err != nil {
+		t.Fatalf("got %v, want the retry to succeed", err)
+	}
+	if got := len(srv.Requests()); got != 2 {
+		t.Errorf("got %d requests, want 2", got)
+	}
+}
+
+func TestClientGetFund(t *testing.T) {
+	srv := newTestServer(t)
+	c := NewClient(WithBaseURL(srv.BaseURL()))
+
+	tests := map[string]struct {
+		symbol  string
+		wantErr bool
+	}{
+		"GGN":     {symbol: "GGN"},
+		"GABprH":  {symbol: "GABprH"},
+		"missing": {symbol: "NOPE", wantErr: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := c.GetFund(tt.symbol)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("%s: got nil error, want error", name)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			if got.Symbol != tt.symbol {
+				t.Errorf("%s: got %v, want %v", name, got.Symbol, tt.symbol)
+			}
+		})
+	}
+}
+
+func TestClientGetCommonFundList(t *testing.T) {
+	srv := newTestServer(t)
+	c := NewClient(WithBaseURL(srv.BaseURL()))
+
+	wantLength := 14
+	got, err := c.GetCommonFundList()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if len(got) != wantLength {
+		t.Errorf("got list with %v Funds, want list with %v Funds", len(got), wantLength)
+	}
+}
+
+func TestClientContext(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Start the body so cancellation lands while it is being read.
+		_, _ = w.Write([]byte("["))
+		w.(http.Flusher).Flush()
+		<-release
+	}))
+	defer ts.Close()
+	defer close(release)
+
+	c := NewClient(WithBaseURL(ts.URL))
+
+	tests := map[string]struct {
+		ctx  func() (context.Context, context.CancelFunc)
+		want error
+	}{
+		"canceled": {
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(10*time.Millisecond, cancel)
+				return ctx, cancel
+			},
+			want: context.Canceled,
+		},
+		"deadline": {
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 10*time.Millisecond)
+			},
+			want: context.DeadlineExceeded,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := tt.ctx()
+			defer cancel()
+
+			_, err := c.GetFundContext(ctx, "GUT")
+			var ctxErr *ContextError
+			if !errors.As(err, &ctxErr) {
+				t.Fatalf("%s: got %v, want *ContextError", name, err)
+			}
+			if !errors.Is(err, tt.want) {
+				t.Errorf("%s: got %v, want %v", name, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientContextAlreadyDone(t *testing.T) {
+	srv := newTestServer(t)
+	c := NewClient(WithBaseURL(srv.BaseURL()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := c.GetCommonFundListContext(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,367 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// maxDecimalExponent bounds the exponent ParseDecimal accepts, so hostile
+// input like "1e999999999" cannot force huge allocations.
+const maxDecimalExponent = 1000
+
+// A Decimal is an exact decimal number, such as a NAV. A Decimal parsed from
+// text keeps that text, so String returns a price exactly as the API sent
+// it, e.g. "43.0", "5." or "1e3"; Canonical formats the number itself.
+//
+// The zero value holds no number: it prints as "", encodes as JSON null and
+// behaves as 0 in arithmetic. It is what a null or missing field decodes to.
+// Decimals are immutable; arithmetic returns new values, which have no text.
+type Decimal struct {
+	// coef is nil for the zero value.
+	coef  *big.Int
+	scale int32
+	// text is what d was parsed from, or "" if it was computed.
+	text string
+}
+
+// NewDecimal returns the Decimal coef × 10^-scale, e.g. NewDecimal(427, 2)
+// is 4.27.
+func NewDecimal(coef int64, scale int32) Decimal {
+	return Decimal{coef: big.NewInt(coef), scale: scale}
+}
+
+// ParseDecimal parses s, written as an optionally signed decimal number with
+// an optional exponent, e.g. "4.27", "-0.000393" or "1.5e-3".
+func ParseDecimal(s string) (Decimal, error) {
+	d, ok := parseDecimal(s)
+	if !ok {
+		return Decimal{}, fmt.Errorf("Invalid decimal %q", s)
+	}
+	return d, nil
+}
+
+// MustParseDecimal is like ParseDecimal but panics if s cannot be parsed.
+func MustParseDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func parseDecimal(s string) (Decimal, bool) {
+	mantissa, exp := s, int64(0)
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		var err error
+		mantissa = s[:i]
+		exp, err = strconv.ParseInt(s[i+1:], 10, 32)
+		if err != nil || exp > maxDecimalExponent || exp < -maxDecimalExponent {
+			return Decimal{}, false
+		}
+	}
+
+	neg := false
+	switch {
+	case strings.HasPrefix(mantissa, "-"):
+		neg = true
+		mantissa = mantissa[1:]
+	case strings.HasPrefix(mantissa, "+"):
+		mantissa = mantissa[1:]
+	}
+
+	intPart, fracPart := mantissa, ""
+	if i := strings.IndexByte(mantissa, '.'); i >= 0 {
+		intPart, fracPart = mantissa[:i], mantissa[i+1:]
+	}
+	if intPart == "" && fracPart == "" {
+		return Decimal{}, false
+	}
+	digits := intPart + fracPart
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return Decimal{}, false
+		}
+	}
+
+	coef, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return Decimal{}, false
+	}
+	if neg {
+		coef.Neg(coef)
+	}
+
+	return Decimal{coef: coef, scale: int32(int64(len(fracPart)) - exp), text: s}, true
+}
+
+// Valid reports whether d holds a number, i.e. it is not the zero value.
+func (d Decimal) Valid() bool {
+	return d.coef != nil
+}
+
+// Scale returns the number of digits after the decimal point.
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+// int returns d's coefficient, treating the zero value as 0.
+func (d Decimal) int() *big.Int {
+	if d.coef == nil {
+		return new(big.Int)
+	}
+	return d.coef
+}
+
+// String returns the text d was parsed from, e.g. "+1.50" or "1e3", and
+// Canonical's formatting for a computed Decimal. The zero value prints as "".
+func (d Decimal) String() string {
+	if d.text != "" {
+		return d.text
+	}
+	return d.Canonical()
+}
+
+// Canonical returns d in plain notation with its scale's digits after the
+// point, however it was written, e.g. "-0.01" for "-1e-2" and "1000" for
+// "1e3". The zero value prints as "".
+func (d Decimal) Canonical() string {
+	if d.coef == nil {
+		return ""
+	}
+	if d.scale <= 0 {
+		s := d.coef.String()
+		if d.coef.Sign() != 0 {
+			s += strings.Repeat("0", int(-d.scale))
+		}
+		return s
+	}
+
+	digits := new(big.Int).Abs(d.coef).String()
+	if pad := int(d.scale) + 1 - len(digits); pad > 0 {
+		digits = strings.Repeat("0", pad) + digits
+	}
+	point := len(digits) - int(d.scale)
+
+	s := digits[:point] + "." + digits[point:]
+	if d.coef.Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+// Float64 returns the float64 nearest to d.
+func (d Decimal) Float64() float64 {
+	if d.coef == nil {
+		return 0
+	}
+	f, _ := strconv.ParseFloat(d.Canonical(), 64)
+	return f
+}
+
+// Sign returns -1, 0 or +1 according to d's sign.
+func (d Decimal) Sign() int {
+	return d.int().Sign()
+}
+
+// IsZero reports whether d is numerically zero. The zero value is.
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// pow10 returns 10^n for n >= 0.
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// rescale returns d's coefficient at the larger scale.
+func (d Decimal) rescale(scale int32) *big.Int {
+	c := d.int()
+	if scale <= d.scale {
+		return new(big.Int).Set(c)
+	}
+	return new(big.Int).Mul(c, pow10(scale-d.scale))
+}
+
+// align returns d's and e's coefficients at their common, larger scale.
+func align(d, e Decimal) (*big.Int, *big.Int, int32) {
+	scale := d.scale
+	if e.scale > scale {
+		scale = e.scale
+	}
+	return d.rescale(scale), e.rescale(scale), scale
+}
+
+// Add returns d + e, at the larger of their scales.
+func (d Decimal) Add(e Decimal) Decimal {
+	a, b, scale := align(d, e)
+	return Decimal{coef: a.Add(a, b), scale: scale}
+}
+
+// Sub returns d - e, at the larger of their scales.
+func (d Decimal) Sub(e Decimal) Decimal {
+	a, b, scale := align(d, e)
+	return Decimal{coef: a.Sub(a, b), scale: scale}
+}
+
+// Mul returns d × e, at the sum of their scales.
+func (d Decimal) Mul(e Decimal) Decimal {
+	return Decimal{coef: new(big.Int).Mul(d.int(), e.int()), scale: d.scale + e.scale}
+}
+
+// Neg returns -d.
+func (d Decimal) Neg() Decimal {
+	return Decimal{coef: new(big.Int).Neg(d.int()), scale: d.scale}
+}
+
+// Abs returns |d|.
+func (d Decimal) Abs() Decimal {
+	return Decimal{coef: new(big.Int).Abs(d.int()), scale: d.scale}
+}
+
+// Div returns d / e rounded half away from zero to places digits after the
+// point. It panics if e is zero.
+func (d Decimal) Div(e Decimal, places int32) Decimal {
+	if e.IsZero() {
+		panic("gamco: Decimal division by zero")
+	}
+
+	// d/e = (D/E) × 10^(e.scale-d.scale), so the result's coefficient is
+	// D × 10^(places+e.scale-d.scale) / E.
+	num, den := new(big.Int).Set(d.int()), new(big.Int).Set(e.int())
+	if shift := places + e.scale - d.scale; shift >= 0 {
+		num.Mul(num, pow10(shift))
+	} else {
+		den.Mul(den, pow10(-shift))
+	}
+
+	return Decimal{coef: quoRound(num, den), scale: places}
+}
+
+// Round returns d rounded half away from zero to places digits after the
+// point. Rounding to more places than d has pads it with zeros.
+func (d Decimal) Round(places int32) Decimal {
+	if places >= d.scale {
+		return Decimal{coef: d.rescale(places), scale: places}
+	}
+	return Decimal{coef: quoRound(new(big.Int).Set(d.int()), pow10(d.scale-places)), scale: places}
+}
+
+// quoRound returns num / den rounded half away from zero.
+func quoRound(num, den *big.Int) *big.Int {
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+
+	// Round away from zero if |2r| >= |den|.
+	r2 := new(big.Int).Abs(r)
+	r2.Lsh(r2, 1)
+	if r2.Cmp(new(big.Int).Abs(den)) >= 0 {
+		if num.Sign()*den.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q
+}
+
+// Cmp compares d and e numerically, returning -1, 0 or +1. Scale is
+// ignored, so 1.0 and 1.00 are equal.
+func (d Decimal) Cmp(e Decimal) int {
+	a, b, _ := align(d, e)
+	return a.Cmp(b)
+}
+
+// Equal reports whether d and e are numerically equal.
+func (d Decimal) Equal(e Decimal) bool {
+	return d.Cmp(e) == 0
+}
+
+// MarshalText encodes d as String does.
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText parses text as ParseDecimal does. Empty text decodes to the
+// zero value.
+func (d *Decimal) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*d = Decimal{}
+		return nil
+	}
+	v, err := ParseDecimal(string(text))
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+// MarshalJSON encodes d as a JSON string, as the API sends prices, or null
+// for the zero value.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	if d.coef == nil {
+		return []byte("null"), nil
+	}
+	return []byte(strconv.Quote(d.String())), nil
+}
+
+// UnmarshalJSON decodes a JSON string or number into d. null and "" decode
+// to the zero value. Anything else is reported as a *json.UnmarshalTypeError,
+// so encoding/json can name the offending field.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		*d = Decimal{}
+		return nil
+	}
+
+	text, kind := data, "number"
+	if len(data) > 0 && data[0] == '"' {
+		s, err := strconv.Unquote(string(data))
+		if err != nil {
+			return &json.UnmarshalTypeError{Value: "string", Type: reflect.TypeOf(d).Elem()}
+		}
+		text, kind = []byte(strings.TrimSpace(s)), "string "+string(data)
+	}
+	if err := d.UnmarshalText(text); err != nil {
+		if len(data) > 0 && data[0] != '"' && (data[0] < '0' || data[0] > '9') && data[0] != '-' {
+			kind = jsonKind(data[0])
+		}
+		return &json.UnmarshalTypeError{Value: kind, Type: reflect.TypeOf(d).Elem()}
+	}
+	return nil
+}
+
+// jsonKind names the kind of JSON value starting with b.
+func jsonKind(b byte) string {
+	switch b {
+	case '{':
+		return "object"
+	case '[':
+		return "array"
+	case 't', 'f':
+		return "bool"
+	}
+	return "value"
+}
@@ -0,0 +1,230 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/jidicula/go-gamco/gamcotest"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := map[string]struct {
+		in        string
+		canonical string
+		wantErr   bool
+	}{
+		"price":          {in: "4.27", canonical: "4.27"},
+		"trailing zero":  {in: "43.0", canonical: "43.0"},
+		"trailing point": {in: "5.", canonical: "5"},
+		"negative":       {in: "-0.000393", canonical: "-0.000393"},
+		"negative zero":  {in: "-0.00", canonical: "0.00"},
+		"integer":        {in: "100", canonical: "100"},
+		"leading point":  {in: ".5", canonical: "0.5"},
+		"plus sign":      {in: "+1.25", canonical: "1.25"},
+		"exponent":       {in: "1.5e-3", canonical: "0.0015"},
+		"big exponent":   {in: "2E3", canonical: "2000"},
+		"many digits":    {in: "12345678901234567890.123456789", canonical: "12345678901234567890.123456789"},
+		"empty":          {in: "", wantErr: true},
+		"point":          {in: ".", wantErr: true},
+		"letters":        {in: "4.2x", wantErr: true},
+		"two points":     {in: "1.2.3", wantErr: true},
+		"huge exponent":  {in: "1e999999", wantErr: true},
+		"bad exponent":   {in: "1e", wantErr: true},
+		"sign only":      {in: "-", wantErr: true},
+		"inner sign":     {in: "1-2", wantErr: true},
+		"space":          {in: " 1", wantErr: true},
+		"negative point": {in: "-.5", canonical: "-0.5"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseDecimal(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("%s: got %v, want error", name, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			if got.String() != tt.in {
+				t.Errorf("%s: got %v, want %v", name, got, tt.in)
+			}
+			if got.Canonical() != tt.canonical {
+				t.Errorf("%s: got canonical %v, want %v", name, got.Canonical(), tt.canonical)
+			}
+		})
+	}
+}
+
+func TestDecimalRoundTripsExample(t *testing.T) {
+	var records []map[string]interface{}
+	if err := json.Unmarshal(gamcotest.Example(), &records); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	for _, r := range records {
+		for _, field := range []string{"price", "prior_price", "change", "pct_change", "sort"} {
+			raw, _ := r[field].(string)
+			d, err := ParseDecimal(raw)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			if d.String() != raw {
+				t.Errorf("%v %s: got %v, want %v", r["id"], field, d, raw)
+			}
+		}
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	d := MustParseDecimal
+	tests := map[string]struct {
+		got  Decimal
+		want string
+	}{
+		"add":            {got: d("4.25").Add(d("0.02")), want: "4.27"},
+		"add scales":     {got: d("43.0").Add(d("0.25")), want: "43.25"},
+		"sub":            {got: d("4.27").Sub(d("4.25")), want: "0.02"},
+		"sub negative":   {got: d("25.42").Sub(d("25.43")), want: "-0.01"},
+		"mul":            {got: d("1.5").Mul(d("0.2")), want: "0.30"},
+		"neg":            {got: d("0.02").Neg(), want: "-0.02"},
+		"abs":            {got: d("-0.02").Abs(), want: "0.02"},
+		"div":            {got: d("0.02").Div(d("4.25"), 6), want: "0.004706"},
+		"div negative":   {got: d("-0.01").Div(d("25.43"), 6), want: "-0.000393"},
+		"div exact":      {got: d("1").Div(d("4"), 2), want: "0.25"},
+		"div round half": {got: d("1").Div(d("8"), 2), want: "0.13"},
+		"div neg half":   {got: d("-1").Div(d("8"), 2), want: "-0.13"},
+		"div neg den":    {got: d("1").Div(d("-8"), 2), want: "-0.13"},
+		"div zero place": {got: d("7").Div(d("2"), 0), want: "4"},
+		"round down":     {got: d("0.004706").Round(3), want: "0.005"},
+		"round half":     {got: d("2.345").Round(2), want: "2.35"},
+		"round neg":      {got: d("-2.345").Round(2), want: "-2.35"},
+		"round pad":      {got: d("2.5").Round(3), want: "2.500"},
+		"zero value add": {got: Decimal{}.Add(d("1.5")), want: "1.5"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := tt.got.String(); got != tt.want {
+				t.Errorf("%s: got %v, want %v", name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecimalDivByZero(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("got no panic, want division by zero")
+		}
+	}()
+	MustParseDecimal("1").Div(MustParseDecimal("0.0"), 2)
+}
+
+func TestDecimalCompare(t *testing.T) {
+	d := MustParseDecimal
+	tests := map[string]struct {
+		a, b Decimal
+		want int
+	}{
+		"equal scales differ": {a: d("1.0"), b: d("1.00"), want: 0},
+		"less":                {a: d("4.25"), b: d("4.27"), want: -1},
+		"greater":             {a: d("0.1"), b: d("0.09"), want: 1},
+		"negative":            {a: d("-0.01"), b: d("0.0"), want: -1},
+		"zero value":          {a: Decimal{}, b: d("0"), want: 0},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := tt.a.Cmp(tt.b); got != tt.want {
+				t.Errorf("%s: got %v, want %v", name, got, tt.want)
+			}
+			if got := tt.a.Equal(tt.b); got != (tt.want == 0) {
+				t.Errorf("%s: Equal got %v, want %v", name, got, tt.want == 0)
+			}
+		})
+	}
+}
+
+func TestDecimalFloat64(t *testing.T) {
+	tests := map[string]struct {
+		in   Decimal
+		want float64
+	}{
+		"price":      {in: MustParseDecimal("4.27"), want: 4.27},
+		"negative":   {in: MustParseDecimal("-0.000393"), want: -0.000393},
+		"exponent":   {in: MustParseDecimal("+1e3"), want: 1000},
+		"new":        {in: NewDecimal(427, 2), want: 4.27},
+		"zero value": {in: Decimal{}, want: 0},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := tt.in.Float64(); got != tt.want {
+				t.Errorf("%s: got %v, want %v", name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecimalJSON(t *testing.T) {
+	tests := map[string]struct {
+		in       string
+		want     string
+		wantJSON string
+		wantErr  bool
+	}{
+		"quoted":        {in: `"4.27"`, want: "4.27", wantJSON: `"4.27"`},
+		"number":        {in: `4.27`, want: "4.27", wantJSON: `"4.27"`},
+		"negative":      {in: `-0.01`, want: "-0.01", wantJSON: `"-0.01"`},
+		"exponent":      {in: `1e-2`, want: "1e-2", wantJSON: `"1e-2"`},
+		"padded string": {in: `" 4.27 "`, want: "4.27", wantJSON: `"4.27"`},
+		"null":          {in: `null`, want: "", wantJSON: `null`},
+		"empty string":  {in: `""`, want: "", wantJSON: `null`},
+		"bad string":    {in: `"n/a"`, wantErr: true},
+		"object":        {in: `{}`, wantErr: true},
+		"bool":          {in: `true`, wantErr: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got Decimal
+			err := json.Unmarshal([]byte(tt.in), &got)
+			if tt.wantErr {
+				if _, ok := err.(*json.UnmarshalTypeError); !ok {
+					t.Errorf("%s: got %v, want *json.UnmarshalTypeError", name, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			if got.String() != tt.want {
+				t.Errorf("%s: got %v, want %v", name, got, tt.want)
+			}
+			b, err := json.Marshal(got)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			if string(b) != tt.wantJSON {
+				t.Errorf("%s: got JSON %s, want %s", name, b, tt.wantJSON)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultCacheDir returns the directory used by WithDiskCache when none is
+// given: go-gamco under the user's cache directory, which is
+// $XDG_CACHE_HOME or ~/.cache on Linux.
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "go-gamco"), nil
+}
+
+// WithDiskCache makes the Client keep the raw nav_closed_ends payload and its
+// ETag and Last-Modified validators under dir, so that short-lived processes
+// share it. If dir is empty, DefaultCacheDir is used; if that cannot be
+// determined, the disk cache is disabled.
+//
+// With a disk cache, each fetch is a conditional request, and a 304 Not
+// Modified answer is served from disk. A copy younger than the Client's
+// cache TTL is served without any request at all. If the API cannot be
+// reached, or keeps failing transiently, the cached copy is served instead
+// and the resulting Snapshot reports Stale. The cache is best effort: errors
+// reading or writing it never fail a lookup.
+func WithDiskCache(dir string) Option {
+	return func(c *Client) {
+		if dir == "" {
+			var err error
+			if dir, err = DefaultCacheDir(); err != nil {
+				c.diskCache = nil
+				return
+			}
+		}
+		c.diskCache = &diskCache{dir: dir}
+	}
+}
+
+// loadPayload returns the nav_closed_ends body, going through the disk cache
+// if the Client has one.
+func (c *Client) loadPayload(ctx context.Context) (Payload, error) {
+	if c.diskCache == nil {
+		a, err := c.getData(ctx, nil)
+		if err != nil {
+			return Payload{}, err
+		}
+		return Payload{Body: a.body, FetchedAt: c.now()}, nil
+	}
+
+	url := c.baseURL + navClosedEndsPath
+	now := c.now()
+
+	// An unreadable cache is an empty one.
+	cached, _ := c.diskCache.load(url)
+	if cached != nil && c.cacheTTL > 0 && now.Sub(cached.FetchedAt) < c.cacheTTL {
+		return Payload{Body: cached.body, FetchedAt: cached.FetchedAt}, nil
+	}
+
+	a, err := c.getData(ctx, cached)
+	if err != nil {
+		if cached != nil && a.retryable && ctx.Err() == nil {
+			return Payload{Body: cached.body, FetchedAt: cached.FetchedAt, Stale: true}, nil
+		}
+		return Payload{}, err
+	}
+
+	e := &cacheEntry{
+		URL:          url,
+		ETag:         a.etag,
+		LastModified: a.lastModified,
+		FetchedAt:    now,
+		body:         a.body,
+	}
+	if a.notModified {
+		e.body = cached.body
+		if e.ETag == "" {
+			e.ETag = cached.ETag
+		}
+		if e.LastModified == "" {
+			e.LastModified = cached.LastModified
+		}
+	}
+	_ = c.diskCache.store(e, !a.notModified)
+
+	return Payload{Body: e.body, FetchedAt: now}, nil
+}
+
+// A diskCache stores payloads as files under dir, keyed by URL.
+type diskCache struct {
+	dir string
+}
+
+// A cacheEntry is a cached payload and its metadata. The metadata is stored
+// as JSON next to the raw body.
+type cacheEntry struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	FetchedAt    time.Time `json:"fetched_at"`
+	BodySHA256   string    `json:"body_sha256"`
+
+	body []byte
+}
+
+// paths returns the body and metadata file paths for url.
+func (dc *diskCache) paths(url string) (body string, meta string) {
+	sum := sha256.Sum256([]byte(url))
+	key := "nav_closed_ends-" + hex.EncodeToString(sum[:8])
+	return filepath.Join(dc.dir, key+".json"), filepath.Join(dc.dir, key+".meta.json")
+}
+
+// load returns the cached entry for url. It returns an error if there is
+// none or it is unreadable or inconsistent.
+func (dc *diskCache) load(url string) (*cacheEntry, error) {
+	bodyPath, metaPath := dc.paths(url)
+
+	m, err := ioutil.ReadFile(metaPath)
+	if err != nil {
+		return nil, err
+	}
+	e := &cacheEntry{}
+	if err = json.Unmarshal(m, e); err != nil {
+		return nil, err
+	}
+
+	e.body, err = ioutil.ReadFile(bodyPath)
+	if err != nil {
+		return nil, err
+	}
+	// The body and metadata are written separately, so another process may
+	// have replaced one but not yet the other.
+	if e.URL != url || sha256Hex(e.body) != e.BodySHA256 {
+		return nil, errors.New("Cache entry is inconsistent")
+	}
+
+	return e, nil
+}
+
+// store writes e to the cache, rewriting its body only if writeBody is set.
+func (dc *diskCache) store(e *cacheEntry, writeBody bool) error {
+	if err := os.MkdirAll(dc.dir, 0o700); err != nil {
+		return err
+	}
+	bodyPath, metaPath := dc.paths(e.URL)
+
+	e.BodySHA256 = sha256Hex(e.body)
+	if writeBody {
+		if err := writeFileAtomic(bodyPath, e.body); err != nil {
+			return err
+		}
+	}
+
+	m, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(metaPath, m)
+}
+
+// writeFileAtomic writes data to a temporary file and renames it to path, so
+// readers never see a partial file.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err = bytes.NewReader(data).WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// sha256Hex returns the hex-encoded SHA-256 digest of b.
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
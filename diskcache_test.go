@@ -0,0 +1,244 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jidicula/go-gamco/gamcotest"
+)
+
+// A validatingServer serves example.json with validators and answers
+// matching conditional requests with 304 Not Modified.
+type validatingServer struct {
+	*httptest.Server
+	full, notModified int32
+	status            int32
+}
+
+func newValidatingServer(t *testing.T, etag string, lastModified string) *validatingServer {
+	t.Helper()
+	payload := gamcotest.Example()
+
+	vs := &validatingServer{status: http.StatusOK}
+	vs.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if status := int(atomic.LoadInt32(&vs.status)); status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		if etag != "" {
+			w.Header().Set("ETag", etag)
+		}
+		if lastModified != "" {
+			w.Header().Set("Last-Modified", lastModified)
+		}
+		inm, ims := r.Header.Get("If-None-Match"), r.Header.Get("If-Modified-Since")
+		if (etag != "" && inm == etag) || (lastModified != "" && ims == lastModified) {
+			atomic.AddInt32(&vs.notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		atomic.AddInt32(&vs.full, 1)
+		_, _ = w.Write(payload)
+	}))
+	t.Cleanup(vs.Close)
+
+	return vs
+}
+
+func TestDiskCacheConditional(t *testing.T) {
+	tests := map[string]struct {
+		etag         string
+		lastModified string
+	}{
+		"etag":          {etag: `"v1"`},
+		"last modified": {lastModified: "Thu, 01 Apr 2021 22:00:00 GMT"},
+		"both":          {etag: `"v1"`, lastModified: "Thu, 01 Apr 2021 22:00:00 GMT"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			vs := newValidatingServer(t, tt.etag, tt.lastModified)
+			dir := t.TempDir()
+
+			// Each Client stands in for a separate short-lived process.
+			for i := 0; i < 3; i++ {
+				c := NewClient(WithBaseURL(vs.URL), WithDiskCache(dir))
+				s, err := c.Snapshot()
+				if err != nil {
+					t.Fatalf(err.Error())
+				}
+				if got := len(s.Funds()); got != 53 {
+					t.Errorf("%s: got %v Funds, want 53", name, got)
+				}
+				if s.Stale() {
+					t.Errorf("%s: got stale Snapshot", name)
+				}
+			}
+
+			if got := atomic.LoadInt32(&vs.full); got != 1 {
+				t.Errorf("%s: got %v full responses, want 1", name, got)
+			}
+			if got := atomic.LoadInt32(&vs.notModified); got != 2 {
+				t.Errorf("%s: got %v 304 responses, want 2", name, got)
+			}
+		})
+	}
+}
+
+func TestDiskCacheFallback(t *testing.T) {
+	tests := map[string]struct {
+		status    int32
+		wantStale bool
+		wantErr   bool
+	}{
+		"server error":       {status: http.StatusServiceUnavailable, wantStale: true},
+		"not found":          {status: http.StatusNotFound, wantErr: true},
+		"reachable and fine": {status: http.StatusOK},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			vs := newValidatingServer(t, `"v1"`, "")
+			dir := t.TempDir()
+			clock := &fakeClock{t: time.Date(2021, 4, 1, 18, 0, 0, 0, time.UTC)}
+			fetchedAt := clock.t
+
+			c := NewClient(WithBaseURL(vs.URL), WithDiskCache(dir), WithRetryPolicy(NoRetry))
+			c.now = clock.now
+			if _, err := c.Snapshot(); err != nil {
+				t.Fatalf(err.Error())
+			}
+
+			atomic.StoreInt32(&vs.status, tt.status)
+			clock.t = clock.t.Add(24 * time.Hour)
+			c = NewClient(WithBaseURL(vs.URL), WithDiskCache(dir), WithRetryPolicy(NoRetry))
+			c.now = clock.now
+
+			s, err := c.Snapshot()
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("%s: got nil error, want error", name)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			if s.Stale() != tt.wantStale {
+				t.Errorf("%s: got Stale %v, want %v", name, s.Stale(), tt.wantStale)
+			}
+			if tt.wantStale && !s.FetchedAt().Equal(fetchedAt) {
+				t.Errorf("%s: got FetchedAt %v, want %v", name, s.FetchedAt(), fetchedAt)
+			}
+		})
+	}
+}
+
+func TestDiskCacheUnreachable(t *testing.T) {
+	vs := newValidatingServer(t, `"v1"`, "")
+	dir := t.TempDir()
+
+	c := NewClient(WithBaseURL(vs.URL), WithDiskCache(dir))
+	if _, err := c.Snapshot(); err != nil {
+		t.Fatalf(err.Error())
+	}
+	vs.Close()
+
+	c = NewClient(WithBaseURL(vs.URL), WithDiskCache(dir), WithRetryPolicy(NoRetry))
+	s, err := c.Snapshot()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !s.Stale() {
+		t.Errorf("got fresh Snapshot, want stale")
+	}
+
+	c = NewClient(WithBaseURL(vs.URL), WithDiskCache(t.TempDir()), WithRetryPolicy(NoRetry))
+	if _, err := c.Snapshot(); err == nil {
+		t.Errorf("got nil error with empty cache, want error")
+	}
+}
+
+func TestDiskCacheTTL(t *testing.T) {
+	vs := newValidatingServer(t, `"v1"`, "")
+	dir := t.TempDir()
+
+	for i := 0; i < 3; i++ {
+		c := NewClient(WithBaseURL(vs.URL), WithDiskCache(dir), WithCacheTTL(time.Hour))
+		if _, err := c.Snapshot(); err != nil {
+			t.Fatalf(err.Error())
+		}
+	}
+
+	if got := atomic.LoadInt32(&vs.full) + atomic.LoadInt32(&vs.notModified); got != 1 {
+		t.Errorf("got %v requests, want 1", got)
+	}
+}
+
+func TestDiskCacheInconsistent(t *testing.T) {
+	vs := newValidatingServer(t, `"v1"`, "")
+	dir := t.TempDir()
+
+	c := NewClient(WithBaseURL(vs.URL), WithDiskCache(dir))
+	if _, err := c.Snapshot(); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	bodyPath, _ := c.diskCache.paths(vs.URL + navClosedEndsPath)
+	if err := ioutil.WriteFile(bodyPath, []byte("[]"), 0o600); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	c = NewClient(WithBaseURL(vs.URL), WithDiskCache(dir))
+	s, err := c.Snapshot()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if got := len(s.Funds()); got != 53 {
+		t.Errorf("got %v Funds, want 53", got)
+	}
+	if got := atomic.LoadInt32(&vs.full); got != 2 {
+		t.Errorf("got %v full responses, want 2", got)
+	}
+}
+
+func TestDefaultCacheDir(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("XDG_CACHE_HOME is only honored on Linux")
+	}
+	xdg := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", xdg)
+
+	got, err := DefaultCacheDir()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if want := filepath.Join(xdg, "go-gamco"); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	c := NewClient(WithDiskCache(""))
+	if c.diskCache == nil || c.diskCache.dir != got {
+		t.Errorf("WithDiskCache(\"\") did not use DefaultCacheDir")
+	}
+}
@@ -0,0 +1,203 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// A DocumentKind is the kind of document a Fund links to.
+type DocumentKind int
+
+// Document kinds, in the order Fund lists them.
+const (
+	AnnualReportDoc DocumentKind = iota
+	SemiAnnualReportDoc
+	QuarterlyReportDoc
+	ProspectusDoc
+	SAIDoc
+	SOIDoc
+	FactsheetDoc
+	CommentaryDoc
+)
+
+var documentKindNames = [...]string{
+	AnnualReportDoc:     "annual report",
+	SemiAnnualReportDoc: "semi-annual report",
+	QuarterlyReportDoc:  "quarterly report",
+	ProspectusDoc:       "prospectus",
+	SAIDoc:              "statement of additional information",
+	SOIDoc:              "schedule of investments",
+	FactsheetDoc:        "factsheet",
+	CommentaryDoc:       "commentary",
+}
+
+// String returns k's name, e.g. "annual report".
+func (k DocumentKind) String() string {
+	if k < 0 || int(k) >= len(documentKindNames) {
+		return fmt.Sprintf("DocumentKind(%d)", int(k))
+	}
+	return documentKindNames[k]
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (k DocumentKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, accepting the names
+// String returns.
+func (k *DocumentKind) UnmarshalText(text []byte) error {
+	for i, name := range documentKindNames {
+		if string(text) == name {
+			*k = DocumentKind(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("Unknown document kind %q", text)
+}
+
+// cadence returns how often documents of kind k are published, or 0 for
+// documents that have no period.
+func (k DocumentKind) cadence() time.Duration {
+	const month = 30 * 24 * time.Hour
+	switch k {
+	case AnnualReportDoc, SemiAnnualReportDoc:
+		// Each is published once a year.
+		return 12 * month
+	case QuarterlyReportDoc, SOIDoc, FactsheetDoc, CommentaryDoc:
+		return 3 * month
+	}
+	return 0
+}
+
+// A Document is a link from a Fund to one of its documents.
+type Document struct {
+	Kind DocumentKind
+	// Raw is the link as the API sent it.
+	Raw string
+	// URL is the parsed link, or nil if it is invalid.
+	URL *url.URL
+	// Err is why the link is invalid, or nil.
+	Err error
+
+	// Period is the reporting period as the link spells it, e.g. "4Q2020",
+	// "12312020" or "2006q3", or "" if the link has none.
+	Period string
+	// PeriodEnd is the last day of Period, or the zero time.
+	PeriodEnd time.Time
+}
+
+// ParseDocument parses a link to a document of the given kind. The link
+// must be an absolute http or https URL. The reporting period is read from
+// the link's path, e.g. the "12312020" of ".../GUTFundWebReady12312020.pdf",
+// the "4Q2020" of ".../closedEnd_FactSheets4Q2020DRAFT_GUT.pdf" or the
+// "2006q3" of ".../2006q3/-113.pdf".
+func ParseDocument(kind DocumentKind, raw string) (Document, error) {
+	d := Document{Kind: kind, Raw: raw}
+	u, err := url.Parse(raw)
+	switch {
+	case err != nil:
+		d.Err = fmt.Errorf("Invalid %s link %q: %w", kind, raw, err)
+	case u.Scheme != "http" && u.Scheme != "https":
+		d.Err = fmt.Errorf("Invalid %s link %q: not an http(s) URL", kind, raw)
+	case u.Host == "":
+		d.Err = fmt.Errorf("Invalid %s link %q: no host", kind, raw)
+	default:
+		d.URL = u
+		d.Period, d.PeriodEnd = documentPeriod(u.Path)
+	}
+
+	return d, d.Err
+}
+
+// Stale reports whether a newer document of d's kind should have been
+// published by asOf, allowing three months after each period for
+// publication. Documents without a period are never stale.
+func (d Document) Stale(asOf time.Time) bool {
+	cadence := d.Kind.cadence()
+	if d.PeriodEnd.IsZero() || cadence == 0 {
+		return false
+	}
+	return asOf.After(d.PeriodEnd.Add(cadence + 3*30*24*time.Hour))
+}
+
+// Documents returns f's document links in field order, skipping absent
+// ones. Invalid links are included, with Err set.
+func (f Fund) Documents() []Document {
+	var docs []Document
+	for _, l := range []struct {
+		kind DocumentKind
+		raw  string
+	}{
+		{AnnualReportDoc, f.AnnualReport},
+		{SemiAnnualReportDoc, f.SemiAnnualReport},
+		{QuarterlyReportDoc, f.QuarterlyReport},
+		{ProspectusDoc, f.Prospectus},
+		{SAIDoc, f.Sai},
+		{SOIDoc, f.Soi},
+		{FactsheetDoc, f.Factsheet},
+		{CommentaryDoc, f.Commentary},
+	} {
+		if l.raw == "" {
+			continue
+		}
+		d, _ := ParseDocument(l.kind, l.raw)
+		docs = append(docs, d)
+	}
+
+	return docs
+}
+
+var (
+	// periodDatePattern matches a period end date, MMDDYYYY, at the end of
+	// a file name.
+	periodDatePattern = regexp.MustCompile(`(\d{2})(\d{2})((?:19|20)\d{2})$`)
+	// periodQuarterPattern matches a quarter such as "4Q2020" or "2006q3".
+	periodQuarterPattern = regexp.MustCompile(`([1-4])[qQ]((?:19|20)\d{2})|((?:19|20)\d{2})[qQ]([1-4])`)
+)
+
+// documentPeriod infers a document's reporting period from its link's
+// path, preferring a date at the end of the file name to a quarter.
+func documentPeriod(p string) (string, time.Time) {
+	name := path.Base(p)
+	name = name[:len(name)-len(path.Ext(name))]
+	if m := periodDatePattern.FindStringSubmatch(name); m != nil {
+		t, err := time.Parse("01022006", m[0])
+		if err == nil {
+			return m[0], t
+		}
+	}
+
+	if m := periodQuarterPattern.FindAllStringSubmatch(p, -1); m != nil {
+		last := m[len(m)-1]
+		q, y := last[1], last[2]
+		if q == "" {
+			q, y = last[4], last[3]
+		}
+		quarter, _ := strconv.Atoi(q)
+		year, _ := strconv.Atoi(y)
+		// The day before the next quarter starts.
+		end := time.Date(year, time.Month(3*quarter+1), 0, 0, 0, 0, 0, time.UTC)
+		return last[0], end
+	}
+
+	return "", time.Time{}
+}
@@ -0,0 +1,178 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jidicula/go-gamco/gamcotest"
+)
+
+func TestParseDocument(t *testing.T) {
+	date := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := map[string]struct {
+		kind       DocumentKind
+		raw        string
+		wantPeriod string
+		wantEnd    time.Time
+		wantErr    bool
+	}{
+		"annual": {
+			kind:       AnnualReportDoc,
+			raw:        "https://gab-annual-reports.s3.us-east-2.amazonaws.com/GUTFundWebReady12312020.pdf",
+			wantPeriod: "12312020",
+			wantEnd:    date(2020, 12, 31),
+		},
+		"digits before date": {
+			kind:       AnnualReportDoc,
+			raw:        "https://gab-annual-reports.s3.us-east-2.amazonaws.com/BCV1010312020.pdf",
+			wantPeriod: "10312020",
+			wantEnd:    date(2020, 10, 31),
+		},
+		"date and quarter": {
+			kind:       CommentaryDoc,
+			raw:        "https://gab-commentary-pdf.s3.us-east-2.amazonaws.com/WEB_CEF_4Q2012312020.pdf",
+			wantPeriod: "12312020",
+			wantEnd:    date(2020, 12, 31),
+		},
+		"quarter in name": {
+			kind:       FactsheetDoc,
+			raw:        "https://gab-factsheets.s3.us-east-2.amazonaws.com/closedEnd_FactSheets4Q2020DRAFT_GUT.pdf",
+			wantPeriod: "4Q2020",
+			wantEnd:    date(2020, 12, 31),
+		},
+		"quarter in path": {
+			kind:       QuarterlyReportDoc,
+			raw:        "https://gab-reports.s3.us-east-2.amazonaws.com/2006q3/-113.pdf",
+			wantPeriod: "2006q3",
+			wantEnd:    date(2006, 9, 30),
+		},
+		"first quarter": {
+			kind:       QuarterlyReportDoc,
+			raw:        "https://gab-reports.s3.us-east-2.amazonaws.com/2018q1/-122.pdf",
+			wantPeriod: "2018q1",
+			wantEnd:    date(2018, 3, 31),
+		},
+		"no period": {
+			kind: ProspectusDoc,
+			raw:  "https://gab-prospectus.s3.us-east-2.amazonaws.com/-113.pdf",
+		},
+		"impossible date": {
+			kind: AnnualReportDoc,
+			raw:  "https://example.com/GUT13312020.pdf",
+		},
+		"relative":    {kind: SAIDoc, raw: "/-113_sai.pdf", wantErr: true},
+		"no host":     {kind: SAIDoc, raw: "https:///-113_sai.pdf", wantErr: true},
+		"ftp":         {kind: SAIDoc, raw: "ftp://example.com/-113_sai.pdf", wantErr: true},
+		"unparseable": {kind: SAIDoc, raw: "https://exa mple.com/%zz", wantErr: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseDocument(tt.kind, tt.raw)
+			if tt.wantErr {
+				if err == nil || got.Err != err || got.URL != nil {
+					t.Errorf("%s: got %+v, %v, want invalid Document", name, got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			if got.Kind != tt.kind || got.Raw != tt.raw || got.URL == nil || got.URL.String() != tt.raw {
+				t.Errorf("%s: got %+v, want link %s", name, got, tt.raw)
+			}
+			if got.Period != tt.wantPeriod || !got.PeriodEnd.Equal(tt.wantEnd) {
+				t.Errorf("%s: got period %q ending %v, want %q ending %v", name, got.Period, got.PeriodEnd, tt.wantPeriod, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestDocumentStale(t *testing.T) {
+	asOf := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		kind DocumentKind
+		raw  string
+		want bool
+	}{
+		"current annual":    {kind: AnnualReportDoc, raw: "https://example.com/GUT12312020.pdf"},
+		"old annual":        {kind: AnnualReportDoc, raw: "https://example.com/GUT09302019.pdf", want: true},
+		"current semi":      {kind: SemiAnnualReportDoc, raw: "https://example.com/GUT06302020.pdf"},
+		"current factsheet": {kind: FactsheetDoc, raw: "https://example.com/GUT4Q2020.pdf"},
+		"old factsheet":     {kind: FactsheetDoc, raw: "https://example.com/GUT3Q2020.pdf", want: true},
+		"2006 quarterly":    {kind: QuarterlyReportDoc, raw: "https://example.com/2006q3/-113.pdf", want: true},
+		"prospectus":        {kind: ProspectusDoc, raw: "https://example.com/2006q3/-113.pdf"},
+		"no period":         {kind: QuarterlyReportDoc, raw: "https://example.com/-113.pdf"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			d, err := ParseDocument(tt.kind, tt.raw)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			if got := d.Stale(asOf); got != tt.want {
+				t.Errorf("%s: got stale %v, want %v", name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFundDocuments(t *testing.T) {
+	s, err := newSnapshot(Payload{Body: gamcotest.Example()})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	gut, _ := s.FundByID(515)
+	docs := gut.Documents()
+	wantKinds := []DocumentKind{AnnualReportDoc, SemiAnnualReportDoc, QuarterlyReportDoc, ProspectusDoc, SAIDoc, FactsheetDoc, CommentaryDoc}
+	if len(docs) != len(wantKinds) {
+		t.Fatalf("got %v documents, want %v", len(docs), len(wantKinds))
+	}
+	for i, d := range docs {
+		if d.Kind != wantKinds[i] {
+			t.Errorf("document %d: got %v, want %v", i, d.Kind, wantKinds[i])
+		}
+		if d.Err != nil {
+			t.Errorf("document %d: %v", i, d.Err)
+		}
+		if want := d.Kind == QuarterlyReportDoc; d.Stale(gut.NAVDate) != want {
+			t.Errorf("document %d: got stale %v, want %v", i, d.Stale(gut.NAVDate), want)
+		}
+	}
+
+	if docs := (Fund{Prospectus: "not a link"}).Documents(); len(docs) != 1 || docs[0].Err == nil {
+		t.Errorf("got %+v, want one invalid Document", docs)
+	}
+	if docs := (Fund{}).Documents(); docs != nil {
+		t.Errorf("got %+v, want none", docs)
+	}
+}
+
+func TestDocumentKindString(t *testing.T) {
+	if got, want := SAIDoc.String(), "statement of additional information"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := DocumentKind(42).String(), "DocumentKind(42)"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,135 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import (
+	"context"
+	"sort"
+)
+
+// A DuplicatePolicy decides which of several Funds sharing a symbol a lookup
+// returns. The feed lists some symbols more than once, e.g. a fund's NAV and
+// its exchange listing; GetFundRecords returns them all.
+type DuplicatePolicy int
+
+// Duplicate policies. LowestID is the default.
+const (
+	// LowestID picks the record with the lowest ID.
+	LowestID DuplicatePolicy = iota
+	// HighestID picks the record with the highest ID.
+	HighestID
+	// LatestNAVDate picks the record with the latest NAVDate, breaking ties
+	// by lowest ID.
+	LatestNAVDate
+	// RejectDuplicates fails the lookup with a *DuplicateError.
+	RejectDuplicates
+)
+
+// WithDuplicatePolicy sets how GetFund picks among Funds sharing a symbol.
+func WithDuplicatePolicy(p DuplicatePolicy) Option {
+	return func(c *Client) {
+		c.duplicates = p
+	}
+}
+
+// resolve picks one of records, which must not be empty, by p.
+func (p DuplicatePolicy) resolve(symbol string, records []Fund) (Fund, error) {
+	if len(records) > 1 && p == RejectDuplicates {
+		return Fund{}, &DuplicateError{Symbol: symbol, IDs: fundIDs(records)}
+	}
+
+	best := records[0]
+	for _, f := range records[1:] {
+		switch p {
+		case HighestID:
+			if f.ID > best.ID {
+				best = f
+			}
+		case LatestNAVDate:
+			if f.NAVDate.After(best.NAVDate) || f.NAVDate.Equal(best.NAVDate) && f.ID < best.ID {
+				best = f
+			}
+		default:
+			if f.ID < best.ID {
+				best = f
+			}
+		}
+	}
+
+	return best, nil
+}
+
+// A Duplicate is a symbol the feed lists more than once.
+type Duplicate struct {
+	Symbol string
+	// Records are the Funds listed under Symbol, in feed order.
+	Records []Fund
+}
+
+// IDs returns the IDs of d's records.
+func (d Duplicate) IDs() []int {
+	return fundIDs(d.Records)
+}
+
+// FundRecords returns every Fund listed under symbol, in feed order.
+func (s *Snapshot) FundRecords(symbol string) []Fund {
+	return s.lookup(s.bySymbol, symbol)
+}
+
+// Duplicates reports every symbol the Snapshot lists more than once, sorted
+// by symbol.
+func (s *Snapshot) Duplicates() []Duplicate {
+	var ds []Duplicate
+	for symbol, idx := range s.bySymbol {
+		if len(idx) > 1 {
+			ds = append(ds, Duplicate{Symbol: symbol, Records: s.lookup(s.bySymbol, symbol)})
+		}
+	}
+	sort.Slice(ds, func(i, j int) bool { return ds[i].Symbol < ds[j].Symbol })
+
+	return ds
+}
+
+// GetFundRecords returns every Fund listed under symbol, or a
+// *NotFoundError if there are none.
+func (c *Client) GetFundRecords(symbol string) ([]Fund, error) {
+	return c.GetFundRecordsContext(context.Background(), symbol)
+}
+
+// GetFundRecordsContext returns every Fund listed under symbol, or a
+// *NotFoundError if there are none. If ctx is canceled or its deadline
+// passes before the Funds are decoded, the returned error is a
+// *ContextError.
+func (c *Client) GetFundRecordsContext(ctx context.Context, symbol string) ([]Fund, error) {
+	s, err := c.SnapshotContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	fl := s.FundRecords(symbol)
+	if len(fl) == 0 {
+		return nil, &NotFoundError{Key: "symbol", Value: symbol}
+	}
+
+	return fl, nil
+}
+
+func fundIDs(fl []Fund) []int {
+	ids := make([]int, len(fl))
+	for i, f := range fl {
+		ids[i] = f.ID
+	}
+	return ids
+}
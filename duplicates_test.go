@@ -0,0 +1,155 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jidicula/go-gamco/gamcotest"
+)
+
+func TestDuplicatePolicyResolve(t *testing.T) {
+	day := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+	records := []Fund{
+		{ID: 516, Symbol: "GUT", NAVDate: day},
+		{ID: 515, Symbol: "GUT", NAVDate: day},
+		{ID: 530, Symbol: "GUT", NAVDate: day.AddDate(0, 0, -1)},
+	}
+	later := append([]Fund{{ID: 600, Symbol: "GUT", NAVDate: day.AddDate(0, 0, 1)}}, records...)
+
+	tests := map[string]struct {
+		policy  DuplicatePolicy
+		records []Fund
+		wantID  int
+		wantErr bool
+	}{
+		"lowest id":          {policy: LowestID, records: records, wantID: 515},
+		"highest id":         {policy: HighestID, records: records, wantID: 530},
+		"latest nav date":    {policy: LatestNAVDate, records: later, wantID: 600},
+		"latest nav tie":     {policy: LatestNAVDate, records: records, wantID: 515},
+		"reject":             {policy: RejectDuplicates, records: records, wantErr: true},
+		"reject single":      {policy: RejectDuplicates, records: records[:1], wantID: 516},
+		"single":             {policy: HighestID, records: records[2:], wantID: 530},
+		"unknown is default": {policy: DuplicatePolicy(99), records: records, wantID: 515},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := tt.policy.resolve("GUT", tt.records)
+			if tt.wantErr {
+				var dupErr *DuplicateError
+				if !errors.As(err, &dupErr) {
+					t.Fatalf("%s: got %v, want *DuplicateError", name, err)
+				}
+				if want := []int{516, 515, 530}; !reflect.DeepEqual(dupErr.IDs, want) {
+					t.Errorf("%s: got IDs %v, want %v", name, dupErr.IDs, want)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			if got.ID != tt.wantID {
+				t.Errorf("%s: got ID %v, want %v", name, got.ID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestSnapshotDuplicates(t *testing.T) {
+	s, err := newSnapshot(Payload{Body: gamcotest.Example()})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	var symbols []string
+	for _, d := range s.Duplicates() {
+		symbols = append(symbols, d.Symbol)
+	}
+	want := []string{
+		"BCV", "ECF", "GAB", "GCV", "GDL", "GDV", "GGN", "GGO",
+		"GGT", "GGZ", "GLU", "GMP LN", "GNT", "GRX", "GUT", "GVP LN",
+	}
+	if !reflect.DeepEqual(symbols, want) {
+		t.Errorf("got duplicates %v, want %v", symbols, want)
+	}
+
+	gut := s.Duplicates()[14]
+	if got, want := gut.IDs(), []int{515, 516}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got GUT IDs %v, want %v", got, want)
+	}
+	if got := s.FundRecords(""); got != nil {
+		t.Errorf("got %v Funds without a symbol, want none indexed", len(got))
+	}
+}
+
+// TestGetFundFeedOrder checks that GetFund's choice among duplicates does
+// not depend on the order of the feed.
+func TestGetFundFeedOrder(t *testing.T) {
+	var records []json.RawMessage
+	if err := json.Unmarshal(gamcotest.Example(), &records); err != nil {
+		t.Fatalf(err.Error())
+	}
+	reversed := make([]json.RawMessage, len(records))
+	for i, r := range records {
+		reversed[len(records)-1-i] = r
+	}
+
+	for name, payload := range map[string]interface{}{"feed order": records, "reversed": reversed} {
+		t.Run(name, func(t *testing.T) {
+			srv := newTestServer(t, gamcotest.WithFunds(payload))
+			c := NewClient(WithBaseURL(srv.BaseURL()), WithCacheTTL(time.Hour))
+
+			for symbol, wantID := range map[string]int{"GUT": 515, "GAB": 502, "GVP LN": 501} {
+				got, err := c.GetFund(symbol)
+				if err != nil {
+					t.Fatalf(err.Error())
+				}
+				if got.ID != wantID {
+					t.Errorf("%s: got ID %v, want %v", symbol, got.ID, wantID)
+				}
+			}
+		})
+	}
+}
+
+func TestClientGetFundRecords(t *testing.T) {
+	srv := newTestServer(t)
+	c := NewClient(WithBaseURL(srv.BaseURL()), WithDuplicatePolicy(RejectDuplicates))
+
+	fl, err := c.GetFundRecords("GAB")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if got, want := fundIDs(fl), []int{502, 538}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got IDs %v, want %v", got, want)
+	}
+
+	if _, err := c.GetFundRecords("NOPE"); !errors.Is(err, ErrFundNotFound) {
+		t.Errorf("got %v, want %v", err, ErrFundNotFound)
+	}
+
+	var dupErr *DuplicateError
+	if _, err := c.GetFund("GAB"); !errors.As(err, &dupErr) || dupErr.Symbol != "GAB" {
+		t.Errorf("got %v, want *DuplicateError for GAB", err)
+	}
+	if f, err := c.GetFund("GABprH"); err != nil || f.ID != 505 {
+		t.Errorf("got %v, %v, want Fund 505", f.ID, err)
+	}
+}
@@ -0,0 +1,192 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ErrFundNotFound is matched by every *NotFoundError, so callers can test for
+// a missing fund with errors.Is.
+var ErrFundNotFound = errors.New("Fund not found")
+
+// A NotFoundError reports that no fund matched a lookup.
+type NotFoundError struct {
+	// Key names what was looked up, e.g. "symbol".
+	Key string
+
+	// Value is the value that matched nothing.
+	Value string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("Fund for %s %s not found", e.Key, e.Value)
+}
+
+// Is reports whether target is ErrFundNotFound.
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrFundNotFound
+}
+
+// apiErrorBodyLimit caps the response body excerpt kept in an APIError.
+const apiErrorBodyLimit = 512
+
+// An APIError reports a non-200 response from the GAMCO API.
+type APIError struct {
+	StatusCode int
+	Header     http.Header
+
+	// Body holds up to the first 512 bytes of the response body.
+	Body []byte
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API call failed, response status %v", e.StatusCode)
+}
+
+// A DuplicateError reports a symbol listed by several Funds, under the
+// RejectDuplicates policy.
+type DuplicateError struct {
+	Symbol string
+	// IDs are the IDs of the Funds listed under Symbol, in feed order.
+	IDs []int
+}
+
+func (e *DuplicateError) Error() string {
+	return fmt.Sprintf("Symbol %s has %d Funds: %v", e.Symbol, len(e.IDs), e.IDs)
+}
+
+// A DecodeError reports a fund record that could not be decoded.
+type DecodeError struct {
+	// Index is the record's position in the payload, or -1 if the payload
+	// as a whole is malformed or the record was decoded on its own.
+	Index int
+
+	// Symbol is the record's symbol, if it could be read.
+	Symbol string
+
+	// Field is the JSON field that failed to decode, if known.
+	Field string
+
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	msg := "Decoding"
+	if e.Index >= 0 {
+		msg += fmt.Sprintf(" fund %d", e.Index)
+	}
+	if e.Symbol != "" {
+		msg += fmt.Sprintf(" (%s)", e.Symbol)
+	}
+	if e.Field != "" {
+		msg += fmt.Sprintf(" field %s", e.Field)
+	}
+	return msg + " failed: " + e.Err.Error()
+}
+
+// Unwrap returns the underlying decoding error.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// A Warning reports a field that could not be decoded. Unlike a
+// DecodeError, it does not fail the record: the field is left zero and the
+// rest of the Fund is usable.
+type Warning struct {
+	// Index is the record's position in the payload, or -1 if the record
+	// was decoded on its own.
+	Index int
+
+	Symbol string
+	Field  string
+
+	// Value is the field's raw JSON value.
+	Value string
+
+	Err error
+}
+
+func (w Warning) String() string {
+	msg := "Field " + w.Field
+	if w.Symbol != "" {
+		msg += " of " + w.Symbol
+	}
+	if w.Index >= 0 {
+		msg += fmt.Sprintf(" (fund %d)", w.Index)
+	}
+	return msg + " left zero: " + w.Err.Error()
+}
+
+// newDecodeError wraps err, returned while decoding the record at index, in
+// a DecodeError, picking up the failing field where encoding/json reports
+// it.
+func newDecodeError(index int, err error) *DecodeError {
+	var de *DecodeError
+	if errors.As(err, &de) {
+		wrapped := *de
+		wrapped.Index = index
+		return &wrapped
+	}
+
+	de = &DecodeError{Index: index, Err: err}
+	var ute *json.UnmarshalTypeError
+	if errors.As(err, &ute) {
+		de.Field = ute.Field
+	}
+	return de
+}
+
+// A ContextError reports that a call was abandoned because its context was
+// canceled or its deadline passed. It unwraps to context.Canceled or
+// context.DeadlineExceeded.
+type ContextError struct {
+	Err error
+}
+
+func (e *ContextError) Error() string {
+	return "Request abandoned: " + e.Err.Error()
+}
+
+// Unwrap returns the context's error.
+func (e *ContextError) Unwrap() error {
+	return e.Err
+}
+
+// contextErr returns a ContextError if ctx is done, and err otherwise.
+func contextErr(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return &ContextError{Err: ctxErr}
+	}
+	return err
+}
+
+// A contextReader stops reading once its context is done.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr *contextReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(p)
+}
@@ -0,0 +1,133 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNotFoundError(t *testing.T) {
+	c := NewClient(WithDataSource(FileSource{Path: "gamcotest/example.json"}))
+
+	_, err := c.GetFund("NOPE")
+	if !errors.Is(err, ErrFundNotFound) {
+		t.Errorf("got %v, want ErrFundNotFound", err)
+	}
+	var nfe *NotFoundError
+	if !errors.As(err, &nfe) {
+		t.Fatalf("got %v, want *NotFoundError", err)
+	}
+	if nfe.Key != "symbol" || nfe.Value != "NOPE" {
+		t.Errorf("got %+v, want symbol NOPE", nfe)
+	}
+	if want := "Fund for symbol NOPE not found"; err.Error() != want {
+		t.Errorf("got %q, want %q", err.Error(), want)
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	body := strings.Repeat("x", 2*apiErrorBodyLimit)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "abc123")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprint(w, body)
+	}))
+	defer ts.Close()
+
+	c := NewClient(WithBaseURL(ts.URL), WithRetryPolicy(NoRetry))
+	_, err := c.GetFund("GUT")
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("got %v, want *APIError", err)
+	}
+	if apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("got status %v, want %v", apiErr.StatusCode, http.StatusServiceUnavailable)
+	}
+	if got := apiErr.Header.Get("X-Request-Id"); got != "abc123" {
+		t.Errorf("got header %q, want %q", got, "abc123")
+	}
+	if len(apiErr.Body) != apiErrorBodyLimit {
+		t.Errorf("got %v byte excerpt, want %v", len(apiErr.Body), apiErrorBodyLimit)
+	}
+}
+
+func TestTransportErrorWrapped(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	ts.Close()
+
+	c := NewClient(WithBaseURL(ts.URL), WithRetryPolicy(NoRetry), WithTimeout(time.Second))
+	_, err := c.GetFund("GUT")
+
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		t.Errorf("got %v, want wrapped *url.Error", err)
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	good := testGUT
+	badID := strings.Replace(testGUT, `"id": 515`, `"id": "515"`, 1)
+	badCode := strings.Replace(testGUT, `"fund_code": -113`, `"fund_code": [-113]`, 1)
+
+	tests := map[string]struct {
+		data       string
+		wantIndex  int
+		wantSymbol string
+		wantField  string
+	}{
+		"malformed payload": {
+			data:      `{"not": "a list"}`,
+			wantIndex: -1,
+		},
+		"wrong type": {
+			data:       fmt.Sprintf("[%s, %s]", good, badID),
+			wantIndex:  1,
+			wantSymbol: "GUT",
+			wantField:  "id",
+		},
+		"wrong kind": {
+			data:       fmt.Sprintf("[%s, %s, %s]", good, good, badCode),
+			wantIndex:  2,
+			wantSymbol: "GUT",
+			wantField:  "fund_code",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := decodeFunds([]byte(tt.data))
+
+			var de *DecodeError
+			if !errors.As(err, &de) {
+				t.Fatalf("%s: got %v, want *DecodeError", name, err)
+			}
+			if de.Index != tt.wantIndex || de.Symbol != tt.wantSymbol || de.Field != tt.wantField {
+				t.Errorf("%s: got index %v symbol %q field %q, want index %v symbol %q field %q",
+					name, de.Index, de.Symbol, de.Field, tt.wantIndex, tt.wantSymbol, tt.wantField)
+			}
+			if de.Unwrap() == nil {
+				t.Errorf("%s: got nil underlying error", name)
+			}
+		})
+	}
+}
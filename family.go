@@ -0,0 +1,261 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// A FundFamily is every security the feed lists for one closed-end fund: its
+// common shares, preferred series and rights.
+type FundFamily struct {
+	// Base is the ticker of the fund's common shares, e.g. "GAB".
+	Base string
+	// Name is the fund's legal name, e.g. "The Gabelli Equity Trust Inc.".
+	Name string
+	// FundCode is the fund's own code, which its members' codes extend; see
+	// Snapshot.FundsByFundCode.
+	FundCode int
+
+	// Common holds the fund's common shares: usually a NAV record and an
+	// exchange listing, or listings on foreign exchanges such as "GMP LN".
+	Common []Fund
+	// Preferreds holds the fund's preferred series, ordered by series.
+	Preferreds []Fund
+	// Rights holds the fund's rights offerings.
+	Rights []Fund
+	// Other holds members whose kind could not be told.
+	Other []Fund
+}
+
+// Funds returns every member of the family.
+func (ff FundFamily) Funds() []Fund {
+	fl := make([]Fund, 0, len(ff.Common)+len(ff.Preferreds)+len(ff.Rights)+len(ff.Other))
+	fl = append(fl, ff.Common...)
+	fl = append(fl, ff.Preferreds...)
+	fl = append(fl, ff.Rights...)
+	return append(fl, ff.Other...)
+}
+
+// Families groups the Snapshot's Funds by fund, sorted by Base.
+//
+// Funds are grouped by the base ticker of their symbols. A Fund without a
+// usable symbol joins the family that shares its CUSIP's six-character
+// issuer prefix, failing that the family whose fund code its own extends,
+// failing that the family with its SeriesName. Its kind is then read from
+// its name, e.g. "Multimedia Trust Pfd G". A Fund matching no family is
+// left in one of its own, with an empty Base.
+func (s *Snapshot) Families() []FundFamily {
+	byBase := map[string]*FundFamily{}
+	var orphans []Fund
+	for _, f := range s.funds {
+		base := f.Security().Base
+		if base == "" {
+			orphans = append(orphans, f)
+			continue
+		}
+		ff := byBase[base]
+		if ff == nil {
+			ff = &FundFamily{Base: base}
+			byBase[base] = ff
+		}
+		ff.add(f, f.Security().Kind)
+	}
+
+	bases := make([]string, 0, len(byBase))
+	for base := range byBase {
+		bases = append(bases, base)
+	}
+	sort.Strings(bases)
+
+	byIssuer := map[string]string{}
+	byCode := map[string]string{}
+	bySeries := map[string]string{}
+	for _, base := range bases {
+		ff := byBase[base]
+		ff.FundCode = familyCode(ff.Funds())
+		byCode[fundCodeDigits(ff.FundCode)] = base
+		for _, f := range ff.Funds() {
+			if issuer := cusipIssuer(f); issuer != "" {
+				byIssuer[issuer] = base
+			}
+			if series := strings.TrimSpace(f.SeriesName); series != "" {
+				bySeries[series] = base
+			}
+		}
+	}
+
+	// Families of orphans have no Base, so they sort first.
+	families := make([]FundFamily, 0, len(byBase)+len(orphans))
+	for _, f := range orphans {
+		base, ok := byIssuer[cusipIssuer(f)]
+		if !ok {
+			base, ok = familyByCode(byCode, f.FundCode)
+		}
+		if !ok {
+			base, ok = bySeries[strings.TrimSpace(f.SeriesName)]
+		}
+		if ok && base != "" {
+			byBase[base].add(f, kindFromName(f))
+			continue
+		}
+		ff := FundFamily{FundCode: f.FundCode}
+		ff.add(f, kindFromName(f))
+		ff.finish()
+		families = append(families, ff)
+	}
+
+	for _, base := range bases {
+		ff := byBase[base]
+		ff.finish()
+		families = append(families, *ff)
+	}
+
+	return families
+}
+
+// Family returns the family of the Fund with the given symbol, which may be
+// any member's, and whether it was found.
+func (s *Snapshot) Family(symbol string) (FundFamily, bool) {
+	sec, err := ParseSymbol(symbol)
+	if err != nil {
+		return FundFamily{}, false
+	}
+	for _, ff := range s.Families() {
+		if ff.Base == sec.Base {
+			return ff, true
+		}
+	}
+	return FundFamily{}, false
+}
+
+// GetFamilies returns every fund's family. See Snapshot.Families.
+func (c *Client) GetFamilies() ([]FundFamily, error) {
+	return c.GetFamiliesContext(context.Background())
+}
+
+// GetFamiliesContext returns every fund's family. See Snapshot.Families. If
+// ctx is canceled or its deadline passes before the Funds are decoded, the
+// returned error is a *ContextError.
+func (c *Client) GetFamiliesContext(ctx context.Context) ([]FundFamily, error) {
+	s, err := c.SnapshotContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Families(), nil
+}
+
+// add files f in ff by kind.
+func (ff *FundFamily) add(f Fund, kind SecurityKind) {
+	switch kind {
+	case Common, ForeignListing:
+		ff.Common = append(ff.Common, f)
+	case Preferred:
+		ff.Preferreds = append(ff.Preferreds, f)
+	case Rights:
+		ff.Rights = append(ff.Rights, f)
+	default:
+		ff.Other = append(ff.Other, f)
+	}
+}
+
+// finish orders ff's preferreds by series and names it.
+func (ff *FundFamily) finish() {
+	sort.SliceStable(ff.Preferreds, func(i, j int) bool {
+		return preferredSeries(ff.Preferreds[i]) < preferredSeries(ff.Preferreds[j])
+	})
+	for _, f := range ff.Funds() {
+		if name := strings.TrimSpace(f.LegalName2); name != "" {
+			ff.Name = name
+			break
+		}
+	}
+}
+
+// familyCode returns the shortest fund code among fl, which its other
+// members' codes extend.
+func familyCode(fl []Fund) int {
+	code := fl[0].FundCode
+	for _, f := range fl[1:] {
+		if len(fundCodeDigits(f.FundCode)) < len(fundCodeDigits(code)) {
+			code = f.FundCode
+		}
+	}
+	return code
+}
+
+// familyByCode returns the family whose code is the longest prefix of code.
+func familyByCode(byCode map[string]string, code int) (string, bool) {
+	digits := fundCodeDigits(code)
+	for n := len(digits); n > 0; n-- {
+		if base, ok := byCode[digits[:n]]; ok {
+			return base, true
+		}
+	}
+	return "", false
+}
+
+// cusipIssuer returns the issuer prefix of f's CUSIP, the first six
+// characters, or "" if f has no CUSIP.
+func cusipIssuer(f Fund) string {
+	for _, id := range []string{f.Cusip, f.SecurityID} {
+		if id = normalizeID(id); len(id) == 9 {
+			return id[:6]
+		}
+	}
+	return ""
+}
+
+// seriesInName matches a preferred series in a name, e.g. the "G" of
+// "Multimedia Trust Pfd G" or "Equity Trust Series G Pfd".
+var seriesInName = regexp.MustCompile(`\b(?:Pfd|Series) ([A-Z])\b`)
+
+// preferredSeries returns f's preferred series letter, from its symbol or
+// else its names.
+func preferredSeries(f Fund) string {
+	if series := f.Security().Series; series != "" {
+		return series
+	}
+	for _, name := range []string{f.FundShortName, f.DisplayName} {
+		if m := seriesInName.FindStringSubmatch(name); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// kindFromName guesses the kind of a Fund without a usable symbol from its
+// names, e.g. "Multimedia Trust Pfd G" or "Dividend & Income Trust Rights".
+func kindFromName(f Fund) SecurityKind {
+	if k := f.Security().Kind; k != UnknownSecurity {
+		return k
+	}
+	for _, name := range []string{f.FundShortName, f.DisplayName} {
+		words := searchTokens(name)
+		for _, w := range words {
+			switch w {
+			case "pfd", "preferred":
+				return Preferred
+			case "rights":
+				return Rights
+			}
+		}
+	}
+	return UnknownSecurity
+}
@@ -0,0 +1,155 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/jidicula/go-gamco/gamcotest"
+)
+
+func TestSnapshotFamilies(t *testing.T) {
+	s, err := newSnapshot(Payload{Body: gamcotest.Example()})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	families := s.Families()
+
+	var bases []string
+	members := 0
+	for _, ff := range families {
+		bases = append(bases, ff.Base)
+		members += len(ff.Funds())
+		if len(ff.Other) > 0 {
+			t.Errorf("%s: got unclassified members %v", ff.Base, fundIDs(ff.Other))
+		}
+	}
+	wantBases := []string{
+		"BCV", "ECF", "GAB", "GCV", "GDL", "GDV", "GGN", "GGO",
+		"GGT", "GGZ", "GLU", "GMP", "GNT", "GRX", "GUT", "GVP",
+	}
+	if !reflect.DeepEqual(bases, wantBases) {
+		t.Errorf("got families %v, want %v", bases, wantBases)
+	}
+	if members != 53 {
+		t.Errorf("got %v members, want all 53 Funds", members)
+	}
+
+	tests := map[string]struct {
+		want FundFamily
+	}{
+		"GAB": {want: FundFamily{
+			Base:       "GAB",
+			Name:       "The Gabelli Equity Trust Inc.",
+			FundCode:   -111,
+			Common:     []Fund{{ID: 502}, {ID: 538}},
+			Preferreds: []Fund{{ID: 512}, {ID: 505}, {ID: 554}, {ID: 721}},
+		}},
+		"GUT RT": {want: FundFamily{
+			Base:       "GUT",
+			Name:       "The Gabelli Utility Trust",
+			FundCode:   -113,
+			Common:     []Fund{{ID: 515}, {ID: 516}},
+			Preferreds: []Fund{{ID: 541}, {ID: 521}},
+			Rights:     []Fund{{ID: 781}},
+		}},
+		// The feed lists these families' Pfd G, rights and Pfd E without
+		// symbols.
+		"GGTprE": {want: FundFamily{
+			Base:       "GGT",
+			Name:       "The Gabelli Multimedia Trust Inc.",
+			FundCode:   -112,
+			Common:     []Fund{{ID: 504}, {ID: 503}},
+			Preferreds: []Fund{{ID: 525}, {ID: 741}},
+		}},
+		"GDV": {want: FundFamily{
+			Base:       "GDV",
+			Name:       "The Gabelli Dividend & Income Trust",
+			FundCode:   -114,
+			Common:     []Fund{{ID: 549}, {ID: 547}},
+			Preferreds: []Fund{{ID: 545}},
+			Rights:     []Fund{{ID: 681}},
+		}},
+		"GMP LN": {want: FundFamily{
+			Base:     "GMP",
+			Name:     "Gabelli Merger Plus+ Trust Plc.",
+			FundCode: -125,
+			Common:   []Fund{{ID: 523}, {ID: 524}},
+		}},
+	}
+
+	for symbol, tt := range tests {
+		t.Run(symbol, func(t *testing.T) {
+			got, ok := s.Family(symbol)
+			if !ok {
+				t.Fatalf("%s: got no family", symbol)
+			}
+			if got.Base != tt.want.Base || got.Name != tt.want.Name || got.FundCode != tt.want.FundCode {
+				t.Errorf("%s: got %s %q %d, want %s %q %d", symbol, got.Base, got.Name, got.FundCode, tt.want.Base, tt.want.Name, tt.want.FundCode)
+			}
+			for kind, pair := range map[string][2][]Fund{
+				"common":     {got.Common, tt.want.Common},
+				"preferreds": {got.Preferreds, tt.want.Preferreds},
+				"rights":     {got.Rights, tt.want.Rights},
+			} {
+				if g, w := fundIDs(pair[0]), fundIDs(pair[1]); !reflect.DeepEqual(g, w) {
+					t.Errorf("%s: got %s %v, want %v", symbol, kind, g, w)
+				}
+			}
+		})
+	}
+
+	if _, ok := s.Family("NOPE"); ok {
+		t.Errorf("got a family for NOPE, want none")
+	}
+	if _, ok := s.Family(""); ok {
+		t.Errorf("got a family for an empty symbol, want none")
+	}
+}
+
+func TestSnapshotFamiliesOrphan(t *testing.T) {
+	orphan := `{"id": 900, "fund_code": -999, "symbol": null, "cusip": "999999109", "fundshortname": "Unknown Trust Pfd A"}`
+	data := fmt.Sprintf("[%s, %s]", testGUT, orphan)
+	s, err := newSnapshot(Payload{Body: []byte(data)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	families := s.Families()
+	if len(families) != 2 {
+		t.Fatalf("got %v families, want 2", len(families))
+	}
+	if got := families[0]; got.Base != "" || len(got.Preferreds) != 1 || got.Preferreds[0].ID != 900 {
+		t.Errorf("got %+v, want a family of its own for the orphan", got)
+	}
+	if got := families[1]; got.Base != "GUT" || len(got.Common) != 1 {
+		t.Errorf("got %+v, want GUT", got)
+	}
+}
+
+func TestClientGetFamilies(t *testing.T) {
+	srv := newTestServer(t)
+	c := NewClient(WithBaseURL(srv.BaseURL()))
+
+	got, err := c.GetFamilies()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if len(got) != 16 {
+		t.Errorf("got %v families, want 16", len(got))
+	}
+}
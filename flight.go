@@ -0,0 +1,93 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import "context"
+
+// A flight is a Snapshot fetch shared by every caller that asks for one while
+// it runs. It is guarded by its Client's mutex until done is closed, after
+// which snap and err are read-only.
+type flight struct {
+	done chan struct{}
+	snap *Snapshot
+	err  error
+
+	// waiters counts callers still waiting on the flight; when the last one
+	// gives up, the fetch is canceled.
+	waiters int
+	cancel  context.CancelFunc
+
+	// generation is the Client's generation when the fetch started.
+	generation uint64
+}
+
+// joinFlight returns the Client's in-flight Snapshot fetch, starting one if
+// none is running, and registers the caller as a waiter.
+//
+// The fetch runs under its own context, detached from any single caller's,
+// so one caller giving up does not fail the others. It is bounded by the
+// Client's timeout and retry policy as usual.
+func (c *Client) joinFlight() *flight {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	f := c.inflight
+	if f == nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		f = &flight{done: make(chan struct{}), cancel: cancel, generation: c.generation}
+		c.inflight = f
+		go c.fly(ctx, f)
+	}
+	f.waiters++
+
+	return f
+}
+
+// leaveFlight unregisters a waiter that gave up on f, canceling the fetch if
+// nobody else is waiting for it.
+func (c *Client) leaveFlight(f *flight) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	f.waiters--
+	if f.waiters > 0 {
+		return
+	}
+	f.cancel()
+	if c.inflight == f {
+		// Later callers must not join a canceled fetch.
+		c.inflight = nil
+	}
+}
+
+// fly runs f's fetch, caches its result unless the Client was invalidated
+// meanwhile, and releases its waiters.
+func (c *Client) fly(ctx context.Context, f *flight) {
+	defer f.cancel()
+
+	s, err := c.fetchSnapshot(ctx)
+
+	c.mu.Lock()
+	if err == nil && c.cacheTTL > 0 && f.generation == c.generation {
+		c.snapshot = s
+	}
+	if c.inflight == f {
+		c.inflight = nil
+	}
+	f.snap, f.err = s, err
+	c.mu.Unlock()
+
+	close(f.done)
+}
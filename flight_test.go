@@ -0,0 +1,197 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jidicula/go-gamco/gamcotest"
+)
+
+// newGatedServer returns a server that holds every request until release is
+// closed, then answers with body and status. It reports requests served and
+// requests abandoned by the client.
+func newGatedServer(t *testing.T, status int, body []byte) (ts *httptest.Server, release chan struct{}, calls, abandoned *int32) {
+	t.Helper()
+	release = make(chan struct{})
+	calls, abandoned = new(int32), new(int32)
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		select {
+		case <-release:
+		case <-r.Context().Done():
+			atomic.AddInt32(abandoned, 1)
+			return
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(ts.Close)
+
+	return ts, release, calls, abandoned
+}
+
+// waitForWaiters blocks until n callers are waiting on c's in-flight fetch.
+func waitForWaiters(t *testing.T, c *Client, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		c.mu.Lock()
+		got := 0
+		if c.inflight != nil {
+			got = c.inflight.waiters
+		}
+		c.mu.Unlock()
+		if got == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %v waiters", n)
+}
+
+func TestSingleFlight(t *testing.T) {
+	payload := gamcotest.Example()
+
+	tests := map[string]struct {
+		status  int
+		wantErr bool
+	}{
+		"shared result": {status: http.StatusOK},
+		"shared error":  {status: http.StatusNotFound, wantErr: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ts, release, calls, _ := newGatedServer(t, tt.status, payload)
+			c := NewClient(WithBaseURL(ts.URL), WithRetryPolicy(NoRetry))
+
+			callers := 20
+			snaps := make([]*Snapshot, callers)
+			errs := make([]error, callers)
+			var wg sync.WaitGroup
+			for i := 0; i < callers; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					snaps[i], errs[i] = c.Refresh()
+				}(i)
+			}
+
+			waitForWaiters(t, c, callers)
+			close(release)
+			wg.Wait()
+
+			if got := atomic.LoadInt32(calls); got != 1 {
+				t.Errorf("%s: got %v upstream requests, want 1", name, got)
+			}
+			for i := 0; i < callers; i++ {
+				if (errs[i] != nil) != tt.wantErr {
+					t.Errorf("%s: caller %v got error %v, want error %v", name, i, errs[i], tt.wantErr)
+				}
+				if snaps[i] != snaps[0] {
+					t.Errorf("%s: caller %v got a different Snapshot", name, i)
+				}
+			}
+		})
+	}
+}
+
+func TestSingleFlightCallerCanceled(t *testing.T) {
+	payload := gamcotest.Example()
+	ts, release, calls, abandoned := newGatedServer(t, http.StatusOK, payload)
+	c := NewClient(WithBaseURL(ts.URL), WithRetryPolicy(NoRetry))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	canceledErr := make(chan error, 1)
+	go func() {
+		_, err := c.RefreshContext(ctx)
+		canceledErr <- err
+	}()
+	waitForWaiters(t, c, 1)
+
+	patientSnap := make(chan *Snapshot, 1)
+	go func() {
+		s, _ := c.Refresh()
+		patientSnap <- s
+	}()
+	waitForWaiters(t, c, 2)
+
+	cancel()
+	if err := <-canceledErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled caller got %v, want %v", err, context.Canceled)
+	}
+
+	close(release)
+	if s := <-patientSnap; s == nil {
+		t.Errorf("patient caller got nil Snapshot")
+	}
+	if got := atomic.LoadInt32(calls); got != 1 {
+		t.Errorf("got %v upstream requests, want 1", got)
+	}
+	if got := atomic.LoadInt32(abandoned); got != 0 {
+		t.Errorf("got %v abandoned requests, want 0", got)
+	}
+}
+
+func TestSingleFlightAllCallersCanceled(t *testing.T) {
+	ts, release, calls, abandoned := newGatedServer(t, http.StatusOK, []byte("[]"))
+	defer close(release)
+	c := NewClient(WithBaseURL(ts.URL), WithRetryPolicy(NoRetry))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.RefreshContext(ctx)
+		done <- err
+	}()
+	waitForWaiters(t, c, 1)
+	// Cancel only once the request is upstream, so there is one to abandon.
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt32(calls) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("upstream request was not made")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+
+	var ctxErr *ContextError
+	if err := <-done; !errors.As(err, &ctxErr) {
+		t.Errorf("got %v, want *ContextError", err)
+	}
+
+	deadline = time.Now().Add(5 * time.Second)
+	for atomic.LoadInt32(abandoned) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("upstream request was not canceled")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	c.mu.Lock()
+	inflight := c.inflight
+	c.mu.Unlock()
+	if inflight != nil {
+		t.Errorf("canceled fetch is still joinable")
+	}
+}
@@ -17,52 +17,29 @@
 package gamco
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"net/http"
 	"strings"
 	"time"
 )
 
-// getData hits the nav_closed_ends endpoint and returns the response byte
-// array.
-func getData() ([]byte, error) {
-	url := "https://gabdotcom-api.com/api/v1/nav_closed_ends"
-	var bodyBytes []byte
-
-	c := http.Client{}
-
-	resp, err := c.Get(url)
-	if err != nil {
-		return bodyBytes, fmt.Errorf("HTTP GET failed: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return bodyBytes, fmt.Errorf("API call failed, response status %v", resp.StatusCode)
-	}
-
-	bodyBytes, err = ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return bodyBytes, fmt.Errorf("Response decoding failed: %v", err)
-	}
-
-	return bodyBytes, err
-}
-
 // A Fund represents a single closed-end GAMCO fund.
+//
+// Prices are exact Decimals. They used to be strings; NAV.String() and
+// friends still return the text exactly as the API sent it, and Canonical
+// formats the number itself.
 type Fund struct {
 	ID                   int       `json:"id"`
 	FundCode             int       `json:"fund_code"`
 	SecurityID           string    `json:"security_id"`
 	FundShortName        string    `json:"fundshortname"`
 	NAVDate              time.Time `json:"pricedate"`
-	NAV                  string    `json:"price"`
-	PriorNAV             string    `json:"prior_price"`
-	Change               string    `json:"change"`
-	PctChange            string    `json:"pct_change"`
-	Sort                 string    `json:"sort"`
+	NAV                  Decimal   `json:"price"`
+	PriorNAV             Decimal   `json:"prior_price"`
+	Change               Decimal   `json:"change"`
+	PctChange            Decimal   `json:"pct_change"`
+	Sort                 Decimal   `json:"sort"`
 	YtdReturn            float64   `json:"ytd_return"`
 	YtdReturnMonthly     float64   `json:"ytd_return_monthly"`
 	YtdReturnQuarterly   float64   `json:"ytd_return_quarterly"`
@@ -82,13 +59,13 @@ type Fund struct {
 	InceptAvgMonthly     float64   `json:"incept_avg_monthly"`
 	InceptAvgQuarterly   float64   `json:"incept_avg_quarterly"`
 	Symbol               string    `json:"symbol"`
-	AssetType            string    `json:"asset_type"`
+	AssetType            AssetType `json:"asset_type"`
 	InceptionDate        time.Time `json:"inception_date"`
 	LegalName2           string    `json:"legalname2"`
 	SeriesName           string    `json:"seriesname"`
 	DisplayName          string    `json:"displayname"`
 	DisplayName_         string    `json:"displayname_"`
-	Category             string    `json:"category"`
+	Category             Category  `json:"category"`
 	AnnualReport         string    `json:"annual_report"`
 	SemiAnnualReport     string    `json:"semi_annual_report"`
 	Cusip                string    `json:"cusip"`
@@ -100,102 +77,360 @@ type Fund struct {
 	Commentary           string    `json:"commentary"`
 	LastMonthEnd         time.Time `json:"last_month_end"`
 	LastQtrEnd2          time.Time `json:"last_qtr_end_2"`
+
+	// nullReturns marks the return fields the API sent as null, or not at
+	// all, by Period and Basis. They read as 0 and are encoded as null.
+	nullReturns [len(periodNames)][len(basisNames)]bool
+
+	// RawAssetType and RawCategory are asset_type and category as the API
+	// sent them, before normalization, e.g. "Covertible Bond" or "Equity ",
+	// or "" for null. They are the only record of an unknown value.
+	RawAssetType string `json:"-"`
+	RawCategory  string `json:"-"`
+
+	// decoded is what decoding reported about the Fund, or nil if nothing.
+	// It is kept behind a pointer so that Fund stays comparable.
+	decoded *fundDecoding
+}
+
+// A fundDecoding is what decoding reported about a Fund beyond its fields.
+type fundDecoding struct {
+	warnings []Warning
+	extra    map[string]json.RawMessage
+}
+
+// Warnings returns the fields that could not be decoded and were left zero.
+func (f Fund) Warnings() []Warning {
+	if f.decoded == nil {
+		return nil
+	}
+	return append([]Warning(nil), f.decoded.warnings...)
+}
+
+// Extra returns the record's fields that Fund does not know, by name, or
+// nil if there are none. It is only filled in by strict decoding; see
+// WithStrictDecoding.
+func (f Fund) Extra() map[string]json.RawMessage {
+	if f.decoded == nil || f.decoded.extra == nil {
+		return nil
+	}
+	extra := make(map[string]json.RawMessage, len(f.decoded.extra))
+	for name, raw := range f.decoded.extra {
+		extra[name] = raw
+	}
+	return extra
+}
+
+// decoding returns f's fundDecoding, allocating it if needed.
+func (f *Fund) decoding() *fundDecoding {
+	if f.decoded == nil {
+		f.decoded = &fundDecoding{}
+	}
+	return f.decoded
 }
 
-// UnmarshalJSON unmarshals data into a Fund.
+// warn records w against f.
+func (f *Fund) warn(w Warning) {
+	d := f.decoding()
+	d.warnings = append(d.warnings, w)
+}
+
+// dateLayouts are the layouts tried, in order, when decoding a date field.
+// The API sends pricedate and inception_date as ISO timestamps and the
+// month and quarter ends as MM/DD/YYYY.
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+	"01/02/2006",
+	"1/2/2006",
+	"2006/01/02",
+}
+
+// parseDate decodes a raw JSON date field. null, "" and a missing field are
+// the zero time.
+func parseDate(raw json.RawMessage) (time.Time, error) {
+	var t time.Time
+	if len(raw) == 0 || string(raw) == "null" {
+		return t, nil
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return t, fmt.Errorf("Date is not a string: %s", raw)
+	}
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return t, nil
+	}
+
+	for _, layout := range dateLayouts {
+		var err error
+		if t, err = time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("Unrecognized date %q", s)
+}
+
+// UnmarshalJSON unmarshals data into a Fund. Dates and prices are decoded
+// tolerantly: one that cannot be parsed is left zero and reported by
+// f.Warnings rather than failing the whole Fund.
 func (f *Fund) UnmarshalJSON(data []byte) error {
 	var err error
 	type _fund Fund
 	var temp struct {
-		RawLastMonthEnd json.RawMessage `json:"last_month_end"`
-		RawLastQtrEnd2  json.RawMessage `json:"last_qtr_end_2"`
+		RawNAVDate       json.RawMessage `json:"pricedate"`
+		RawInceptionDate json.RawMessage `json:"inception_date"`
+		RawLastMonthEnd  json.RawMessage `json:"last_month_end"`
+		RawLastQtrEnd2   json.RawMessage `json:"last_qtr_end_2"`
+		RawNAV           json.RawMessage `json:"price"`
+		RawPriorNAV      json.RawMessage `json:"prior_price"`
+		RawChange        json.RawMessage `json:"change"`
+		RawPctChange     json.RawMessage `json:"pct_change"`
+		RawSort          json.RawMessage `json:"sort"`
+		RawAssetType     *string         `json:"asset_type"`
+		RawCategory      *string         `json:"category"`
 		_fund
 	}
-	dateFormat := "01/02/2006"
 	if err = json.Unmarshal(data, &temp); err != nil {
-		return err
+		return newDecodeError(-1, err)
 	}
 
 	// unmarshal other fields
 	*f = Fund(temp._fund)
+	if temp.RawAssetType != nil {
+		f.RawAssetType = *temp.RawAssetType
+	}
+	if temp.RawCategory != nil {
+		f.RawCategory = *temp.RawCategory
+	}
+	f.AssetType = ParseAssetType(f.RawAssetType)
+	f.Category = ParseCategory(f.RawCategory)
 
-	rawlastMonthEnd := strings.Trim(string(temp.RawLastMonthEnd), `"`)
-	f.LastMonthEnd, err = time.Parse(dateFormat, rawlastMonthEnd)
-	if err != nil {
-		return err
+	var fields map[string]json.RawMessage
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return newDecodeError(-1, err)
+	}
+	for p := range returnFields {
+		for b, name := range returnFields[p] {
+			raw, ok := fields[name]
+			f.nullReturns[p][b] = !ok || string(raw) == "null"
+		}
 	}
 
-	rawlastQtrEnd2 := strings.Trim(string(temp.RawLastQtrEnd2), `"`)
-	f.LastQtrEnd2, err = time.Parse(dateFormat, rawlastQtrEnd2)
-	if err != nil {
-		return err
+	// Prices are decoded here rather than by encoding/json so that one
+	// that cannot be parsed, such as "N/A", is left zero and reported by
+	// f.Warnings, like a date.
+	prices := []struct {
+		field string
+		raw   json.RawMessage
+		dst   *Decimal
+	}{
+		{"price", temp.RawNAV, &f.NAV},
+		{"prior_price", temp.RawPriorNAV, &f.PriorNAV},
+		{"change", temp.RawChange, &f.Change},
+		{"pct_change", temp.RawPctChange, &f.PctChange},
+		{"sort", temp.RawSort, &f.Sort},
+	}
+	for _, p := range prices {
+		if len(p.raw) == 0 {
+			continue
+		}
+		if priceErr := p.dst.UnmarshalJSON(p.raw); priceErr != nil {
+			*p.dst = Decimal{}
+			f.warn(Warning{
+				Index:  -1,
+				Symbol: f.Symbol,
+				Field:  p.field,
+				Value:  string(p.raw),
+				Err:    priceErr,
+			})
+		}
 	}
-	return err
-}
 
-// A fundMap represents a map of Fund objects with their symbols as keys.
-type fundMap map[string]Fund
+	dates := []struct {
+		field string
+		raw   json.RawMessage
+		dst   *time.Time
+	}{
+		{"pricedate", temp.RawNAVDate, &f.NAVDate},
+		{"inception_date", temp.RawInceptionDate, &f.InceptionDate},
+		{"last_month_end", temp.RawLastMonthEnd, &f.LastMonthEnd},
+		{"last_qtr_end_2", temp.RawLastQtrEnd2, &f.LastQtrEnd2},
+	}
+	for _, d := range dates {
+		var dateErr error
+		if *d.dst, dateErr = parseDate(d.raw); dateErr != nil {
+			f.warn(Warning{
+				Index:  -1,
+				Symbol: f.Symbol,
+				Field:  d.field,
+				Value:  string(d.raw),
+				Err:    dateErr,
+			})
+		}
+	}
 
-// UnmarshalJSON unmarshals JSON data into a FundMap
-func (f *fundMap) UnmarshalJSON(data []byte) error {
-	*f = make(fundMap)
+	return nil
+}
+
+// decodeFunds decodes a nav_closed_ends payload into its Funds, in feed
+// order. Dates and prices that cannot be parsed only add Warnings to their
+// Fund; a record that is malformed otherwise, such as a non-numeric id,
+// fails the whole payload with a *DecodeError locating it.
+func decodeFunds(data []byte) ([]Fund, error) {
+	var records []json.RawMessage
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, newDecodeError(-1, err)
+	}
 
-	var fundList []*Fund
-	if err := json.Unmarshal(data, &fundList); err != nil {
-		return err
+	fl := make([]Fund, len(records))
+	for i, r := range records {
+		if err := decodeRecord(i, r, &fl[i]); err != nil {
+			return nil, err
+		}
 	}
 
-	for _, v := range fundList {
-		(*f)[v.Symbol] = *v
+	return fl, nil
+}
+
+// decodeRecord decodes the raw fund record at index into f.
+func decodeRecord(index int, r json.RawMessage, f *Fund) error {
+	if err := json.Unmarshal(r, f); err != nil {
+		de := newDecodeError(index, err)
+		if de.Symbol == "" {
+			de.Symbol = recordSymbol(r)
+		}
+		return de
+	}
+	if f.decoded != nil {
+		for j := range f.decoded.warnings {
+			f.decoded.warnings[j].Index = index
+		}
 	}
 
 	return nil
 }
 
-// GetFund returns the symbol's matching Fund.
+// recordSymbol returns the symbol of a raw fund record, or "" if it cannot
+// be read.
+func recordSymbol(r json.RawMessage) string {
+	var rec struct {
+		Symbol string `json:"symbol"`
+	}
+	_ = json.Unmarshal(r, &rec)
+	return rec.Symbol
+}
+
+// GetFund returns the symbol's matching Fund using the default Client.
 func GetFund(symbol string) (Fund, error) {
-	f := Fund{}
-	fm := fundMap{}
+	return defaultClient.GetFund(symbol)
+}
 
-	d, err := getData()
-	if err != nil {
-		return f, err
-	}
+// GetFundContext returns the symbol's matching Fund using the default Client,
+// abandoning the call once ctx is done.
+func GetFundContext(ctx context.Context, symbol string) (Fund, error) {
+	return defaultClient.GetFundContext(ctx, symbol)
+}
 
-	err = fm.UnmarshalJSON(d)
-	if err != nil {
-		return f, err
-	}
+// GetFundRecords returns every Fund listed under symbol using the default
+// Client.
+func GetFundRecords(symbol string) ([]Fund, error) {
+	return defaultClient.GetFundRecords(symbol)
+}
 
-	f, ok := fm[symbol]
-	if !ok {
-		return f, fmt.Errorf("Fund for symbol %s not found", symbol)
-	}
+// GetFundRecordsContext returns every Fund listed under symbol using the
+// default Client, abandoning the call once ctx is done.
+func GetFundRecordsContext(ctx context.Context, symbol string) ([]Fund, error) {
+	return defaultClient.GetFundRecordsContext(ctx, symbol)
+}
 
-	return f, nil
+// GetFundByID returns the Fund with the given ID using the default Client.
+func GetFundByID(id int) (Fund, error) {
+	return defaultClient.GetFundByID(id)
 }
 
-// GetCommonFundList returns a list of common GAMCO Funds.
-func GetCommonFundList() ([]Fund, error) {
-	fl := []Fund{}
+// GetFundByIDContext returns the Fund with the given ID using the default
+// Client, abandoning the call once ctx is done.
+func GetFundByIDContext(ctx context.Context, id int) (Fund, error) {
+	return defaultClient.GetFundByIDContext(ctx, id)
+}
 
-	d, err := getData()
-	if err != nil {
-		return fl, err
-	}
+// GetFundByCUSIP returns the Fund with the given CUSIP or US ISIN using the
+// default Client.
+func GetFundByCUSIP(cusip string) (Fund, error) {
+	return defaultClient.GetFundByCUSIP(cusip)
+}
 
-	err = json.Unmarshal(d, &fl)
-	if err != nil {
-		return fl, err
-	}
+// GetFundByCUSIPContext returns the Fund with the given CUSIP using the
+// default Client, abandoning the call once ctx is done.
+func GetFundByCUSIPContext(ctx context.Context, cusip string) (Fund, error) {
+	return defaultClient.GetFundByCUSIPContext(ctx, cusip)
+}
 
-	// filter only common stock
-	flCommon := []Fund{}
-	for _, v := range fl {
-		if v.AnnualReport != "" && len(v.Symbol) == 3 {
-			flCommon = append(flCommon, v)
-		}
-	}
+// GetFundBySecurityID returns the Fund with the given security ID using the
+// default Client.
+func GetFundBySecurityID(id string) (Fund, error) {
+	return defaultClient.GetFundBySecurityID(id)
+}
+
+// GetFundBySecurityIDContext returns the Fund with the given security ID using
+// the default Client, abandoning the call once ctx is done.
+func GetFundBySecurityIDContext(ctx context.Context, id string) (Fund, error) {
+	return defaultClient.GetFundBySecurityIDContext(ctx, id)
+}
+
+// GetFundsByFundCode returns the Funds with fund code code and those whose
+// codes extend it using the default Client.
+func GetFundsByFundCode(code int) ([]Fund, error) {
+	return defaultClient.GetFundsByFundCode(code)
+}
+
+// GetFundsByFundCodeContext returns the Funds with fund code code and those
+// whose codes extend it using the default Client, abandoning the call once
+// ctx is done.
+func GetFundsByFundCodeContext(ctx context.Context, code int) ([]Fund, error) {
+	return defaultClient.GetFundsByFundCodeContext(ctx, code)
+}
+
+// Search returns the Funds whose names match query, best first, using the
+// default Client.
+func Search(query string, opts ...SearchOption) ([]SearchResult, error) {
+	return defaultClient.Search(query, opts...)
+}
+
+// SearchContext returns the Funds whose names match query, best first, using
+// the default Client, abandoning the call once ctx is done.
+func SearchContext(ctx context.Context, query string, opts ...SearchOption) ([]SearchResult, error) {
+	return defaultClient.SearchContext(ctx, query, opts...)
+}
+
+// GetFamilies returns every fund's family using the default Client.
+func GetFamilies() ([]FundFamily, error) {
+	return defaultClient.GetFamilies()
+}
+
+// GetFamiliesContext returns every fund's family using the default Client,
+// abandoning the call once ctx is done.
+func GetFamiliesContext(ctx context.Context) ([]FundFamily, error) {
+	return defaultClient.GetFamiliesContext(ctx)
+}
+
+// DownloadDocuments fetches every document linked from funds into a using
+// the default Client.
+func DownloadDocuments(ctx context.Context, a *Archive, funds []Fund, opts ...DownloadOption) (DownloadResult, error) {
+	return defaultClient.DownloadDocuments(ctx, a, funds, opts...)
+}
+
+// GetCommonFundList returns a list of common GAMCO Funds using the default
+// Client.
+func GetCommonFundList() ([]Fund, error) {
+	return defaultClient.GetCommonFundList()
+}
 
-	return flCommon, nil
+// GetCommonFundListContext returns a list of common GAMCO Funds using the
+// default Client, abandoning the call once ctx is done.
+func GetCommonFundListContext(ctx context.Context) ([]Fund, error) {
+	return defaultClient.GetCommonFundListContext(ctx)
 }
@@ -15,6 +15,7 @@
 package gamco
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"reflect"
@@ -22,9 +23,23 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/jidicula/go-gamco/gamcotest"
 )
 
+// useTestServer points the default Client at a fake GAMCO API for the rest
+// of the test.
+func useTestServer(t *testing.T) *gamcotest.Server {
+	t.Helper()
+	srv := newTestServer(t)
+	orig := defaultClient
+	defaultClient = NewClient(WithBaseURL(srv.BaseURL()))
+	t.Cleanup(func() { defaultClient = orig })
+	return srv
+}
+
 func TestGetData(t *testing.T) {
+	useTestServer(t)
 	tests := map[string]struct {
 		want *regexp.Regexp
 	}{
@@ -37,12 +52,12 @@ func TestGetData(t *testing.T) {
 
 		t.Run(name, func(t *testing.T) {
 
-			got, err := getData()
+			got, err := defaultClient.getData(context.Background(), nil)
 			if err != nil {
 				t.Fatalf(err.Error())
 			}
-			if !tt.want.MatchString(string(got)) {
-				t.Errorf("%s: got %s, want `%s` regex match", name, got, tt.want.String())
+			if !tt.want.MatchString(string(got.body)) {
+				t.Errorf("%s: got %s, want `%s` regex match", name, got.body, tt.want.String())
 			}
 		})
 	}
@@ -119,11 +134,11 @@ func TestFundUnmarshal(t *testing.T) {
 			SecurityID:           "36240A101",
 			FundShortName:        "Utility Trust",
 			NAVDate:              priceDate,
-			NAV:                  "4.27",
-			PriorNAV:             "4.25",
-			Change:               "0.02",
-			PctChange:            "0.004706",
-			Sort:                 "43.0",
+			NAV:                  MustParseDecimal("4.27"),
+			PriorNAV:             MustParseDecimal("4.25"),
+			Change:               MustParseDecimal("0.02"),
+			PctChange:            MustParseDecimal("0.004706"),
+			Sort:                 MustParseDecimal("43.0"),
 			YtdReturn:            0.0767238547,
 			YtdReturnMonthly:     0.0716806516,
 			YtdReturnQuarterly:   0.0716806516,
@@ -150,6 +165,8 @@ func TestFundUnmarshal(t *testing.T) {
 			DisplayName:          "Gabelli Utility Trust",
 			DisplayName_:         "The Gabelli Utility Trust",
 			Category:             "value",
+			RawAssetType:         "Equity",
+			RawCategory:          "value",
 			AnnualReport:         "https://gab-annual-reports.s3.us-east-2.amazonaws.com/GUTFundWebReady12312020.pdf",
 			SemiAnnualReport:     "https://gab-semi-annuals.s3.us-east-2.amazonaws.com/TheGabelliUtilityTrust606302020.pdf",
 			Cusip:                "36240A101",
@@ -179,7 +196,7 @@ func TestFundUnmarshal(t *testing.T) {
 
 }
 
-func TestFundMapUnmarshal(t *testing.T) {
+func TestSnapshotFund(t *testing.T) {
 	// Date setup
 	dates, err := dateSetup("2021-04-01T00:00:00.000Z", "1999-07-09T00:00:00.000Z", "03/31/2021", "03/31/2021")
 	if err != nil {
@@ -196,21 +213,21 @@ func TestFundMapUnmarshal(t *testing.T) {
 
 	tests := map[string]struct {
 		data []byte
-		want fundMap
+		want map[string]Fund
 	}{
 		"two funds": {
 			data: []byte(data),
-			want: fundMap{"GUT": Fund{
+			want: map[string]Fund{"GUT": Fund{
 				ID:                   515,
 				FundCode:             -113,
 				SecurityID:           "36240A101",
 				FundShortName:        "Utility Trust",
 				NAVDate:              priceDate,
-				NAV:                  "4.27",
-				PriorNAV:             "4.25",
-				Change:               "0.02",
-				PctChange:            "0.004706",
-				Sort:                 "43.0",
+				NAV:                  MustParseDecimal("4.27"),
+				PriorNAV:             MustParseDecimal("4.25"),
+				Change:               MustParseDecimal("0.02"),
+				PctChange:            MustParseDecimal("0.004706"),
+				Sort:                 MustParseDecimal("43.0"),
 				YtdReturn:            0.0767238547,
 				YtdReturnMonthly:     0.0716806516,
 				YtdReturnQuarterly:   0.0716806516,
@@ -237,6 +254,8 @@ func TestFundMapUnmarshal(t *testing.T) {
 				DisplayName:          "Gabelli Utility Trust",
 				DisplayName_:         "The Gabelli Utility Trust",
 				Category:             "value",
+				RawAssetType:         "Equity",
+				RawCategory:          "value",
 				AnnualReport:         "https://gab-annual-reports.s3.us-east-2.amazonaws.com/GUTFundWebReady12312020.pdf",
 				SemiAnnualReport:     "https://gab-semi-annuals.s3.us-east-2.amazonaws.com/TheGabelliUtilityTrust606302020.pdf",
 				Cusip:                "36240A101",
@@ -254,11 +273,11 @@ func TestFundMapUnmarshal(t *testing.T) {
 				SecurityID:           "36240A101",
 				FundShortName:        "Utility Trust",
 				NAVDate:              priceDate,
-				NAV:                  "4.27",
-				PriorNAV:             "4.25",
-				Change:               "0.02",
-				PctChange:            "0.004706",
-				Sort:                 "43.0",
+				NAV:                  MustParseDecimal("4.27"),
+				PriorNAV:             MustParseDecimal("4.25"),
+				Change:               MustParseDecimal("0.02"),
+				PctChange:            MustParseDecimal("0.004706"),
+				Sort:                 MustParseDecimal("43.0"),
 				YtdReturn:            0.0767238547,
 				YtdReturnMonthly:     0.0716806516,
 				YtdReturnQuarterly:   0.0716806516,
@@ -285,6 +304,8 @@ func TestFundMapUnmarshal(t *testing.T) {
 				DisplayName:          "Gabelli Utility Trust",
 				DisplayName_:         "The Gabelli Utility Trust",
 				Category:             "value",
+				RawAssetType:         "Equity",
+				RawCategory:          "value",
 				AnnualReport:         "https://gab-annual-reports.s3.us-east-2.amazonaws.com/GGTFundWebReady12312020.pdf",
 				SemiAnnualReport:     "https://gab-semi-annuals.s3.us-east-2.amazonaws.com/TheGabelliUtilityTrust606302020.pdf",
 				Cusip:                "36240A101",
@@ -302,11 +323,14 @@ func TestFundMapUnmarshal(t *testing.T) {
 
 	for name, tt := range tests {
 		t.Run(name, func(t *testing.T) {
-			got := fundMap{}
-			err := json.Unmarshal(tt.data, &got)
+			s, err := newSnapshot(Payload{Body: tt.data})
 			if err != nil {
 				t.Fatalf(err.Error())
 			}
+			got := map[string]Fund{}
+			for symbol := range tt.want {
+				got[symbol], _ = s.Fund(symbol)
+			}
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("%s: got %v, want %v", name, got, tt.want)
 			}
@@ -314,6 +338,139 @@ func TestFundMapUnmarshal(t *testing.T) {
 	}
 }
 
+func TestFundUnmarshalDates(t *testing.T) {
+	lastMonthEnd := time.Date(2021, 3, 31, 0, 0, 0, 0, time.UTC)
+	tests := map[string]struct {
+		raw         string
+		want        time.Time
+		wantWarning bool
+	}{
+		"month/day/year":     {raw: `"03/31/2021"`, want: lastMonthEnd},
+		"unpadded":           {raw: `"3/31/2021"`, want: lastMonthEnd},
+		"iso date":           {raw: `"2021-03-31"`, want: lastMonthEnd},
+		"iso timestamp":      {raw: `"2021-03-31T00:00:00.000Z"`, want: lastMonthEnd},
+		"timestamp no zone":  {raw: `"2021-03-31T00:00:00"`, want: lastMonthEnd},
+		"slashed iso":        {raw: `"2021/03/31"`, want: lastMonthEnd},
+		"null":               {raw: `null`},
+		"empty":              {raw: `""`},
+		"blank":              {raw: `"  "`},
+		"unrecognized":       {raw: `"March 2021"`, wantWarning: true},
+		"impossible":         {raw: `"02/31/2021"`, wantWarning: true},
+		"number":             {raw: `20210331`, wantWarning: true},
+		"padded":             {raw: `" 03/31/2021 "`, want: lastMonthEnd},
+		"two digit year":     {raw: `"03/31/21"`, wantWarning: true},
+		"iso with offset":    {raw: `"2021-03-31T00:00:00+00:00"`, want: lastMonthEnd},
+		"iso nanos":          {raw: `"2021-03-31T00:00:00.000000000Z"`, want: lastMonthEnd},
+		"iso without millis": {raw: `"2021-03-31T00:00:00Z"`, want: lastMonthEnd},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			data := strings.Replace(testGUT, `"last_month_end": "03/31/2021"`, `"last_month_end": `+tt.raw, 1)
+			got := Fund{}
+			if err := json.Unmarshal([]byte(data), &got); err != nil {
+				t.Fatalf(err.Error())
+			}
+			if !got.LastMonthEnd.Equal(tt.want) {
+				t.Errorf("%s: got %v, want %v", name, got.LastMonthEnd, tt.want)
+			}
+			if gotWarning := len(got.Warnings()) > 0; gotWarning != tt.wantWarning {
+				t.Errorf("%s: got warnings %v, want warning %v", name, got.Warnings(), tt.wantWarning)
+			}
+			if tt.wantWarning && got.Warnings()[0].Field != "last_month_end" {
+				t.Errorf("%s: got warning for %s, want last_month_end", name, got.Warnings()[0].Field)
+			}
+		})
+	}
+}
+
+func TestDecodeFundsWarnings(t *testing.T) {
+	badDates := strings.NewReplacer(
+		`"pricedate": "2021-04-01T00:00:00.000Z"`, `"pricedate": "yesterday"`,
+		`"last_qtr_end_2": "03/31/2021"`, `"last_qtr_end_2": null`,
+	).Replace(testGUT)
+	data := fmt.Sprintf("[%s, %s, %s]", testGUT, badDates, testGUT)
+
+	fl, err := decodeFunds([]byte(data))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if len(fl) != 3 {
+		t.Fatalf("got %v Funds, want 3", len(fl))
+	}
+
+	s := &Snapshot{funds: fl}
+	ws := s.Warnings()
+	if len(ws) != 1 {
+		t.Fatalf("got warnings %v, want 1", ws)
+	}
+	if ws[0].Index != 1 || ws[0].Field != "pricedate" || ws[0].Symbol != "GUT" {
+		t.Errorf("got %+v, want pricedate warning for fund 1", ws[0])
+	}
+	if !fl[1].NAVDate.IsZero() || !fl[1].LastQtrEnd2.IsZero() {
+		t.Errorf("got dates %v and %v, want zero", fl[1].NAVDate, fl[1].LastQtrEnd2)
+	}
+	if fl[1].NAV.String() != "4.27" {
+		t.Errorf("got NAV %v, want the rest of the Fund decoded", fl[1].NAV)
+	}
+}
+
+func TestDecodeFundsPriceWarnings(t *testing.T) {
+	badPrices := strings.NewReplacer(
+		`"price": "4.27"`, `"price": "N/A"`,
+		`"sort": "43.0"`, `"sort": {}`,
+	).Replace(testGUT)
+	data := fmt.Sprintf("[%s, %s, %s]", testGUT, badPrices, testGUT)
+
+	fl, err := decodeFunds([]byte(data))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if len(fl) != 3 {
+		t.Fatalf("got %v Funds, want 3", len(fl))
+	}
+
+	ws := (&Snapshot{funds: fl}).Warnings()
+	var fields []string
+	for _, w := range ws {
+		if w.Index != 1 || w.Symbol != "GUT" {
+			t.Errorf("got %+v, want a warning for fund 1", w)
+		}
+		fields = append(fields, w.Field)
+	}
+	if want := []string{"price", "sort"}; !reflect.DeepEqual(fields, want) {
+		t.Errorf("got warnings for %v, want %v", fields, want)
+	}
+	if fl[1].NAV.Valid() || fl[1].Sort.Valid() {
+		t.Errorf("got NAV %v and sort %v, want zero", fl[1].NAV, fl[1].Sort)
+	}
+	if fl[1].PriorNAV.String() != "4.25" {
+		t.Errorf("got prior NAV %v, want the rest of the Fund decoded", fl[1].PriorNAV)
+	}
+}
+
+func TestFundComparable(t *testing.T) {
+	badDate := strings.Replace(testGUT, `"pricedate": "2021-04-01T00:00:00.000Z"`, `"pricedate": "yesterday"`, 1)
+	data := strings.Replace(fmt.Sprintf("[%s, %s]", testGUT, badDate), `"id": 515`, `"id": 515, "sort_order": 1`, 1)
+	fl, _, err := decodeFundsStrict([]byte(data))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// Fund must stay usable with == and as a map key, even with warnings
+	// and extra fields.
+	seen := map[Fund]bool{}
+	for _, f := range fl {
+		seen[f] = true
+	}
+	if len(seen) != len(fl) {
+		t.Errorf("got %v distinct Funds, want %v", len(seen), len(fl))
+	}
+	if g := fl[1]; g != fl[1] || len(g.Warnings()) != 1 || fl[0].Extra() == nil {
+		t.Errorf("got %+v, want a copy equal to the original, with its warnings and extra fields", g)
+	}
+}
+
 // dateSetup sets up a map of times for use in tests
 func dateSetup(priceDate string, inceptionDate string, lastMonthEnd string, lastQtrEnd string) (map[string]time.Time, error) {
 	dates := make(map[string]time.Time)
@@ -340,6 +497,7 @@ func dateSetup(priceDate string, inceptionDate string, lastMonthEnd string, last
 }
 
 func TestGetFund(t *testing.T) {
+	useTestServer(t)
 	tests := map[string]struct {
 		symbol string
 		want   Fund
@@ -350,11 +508,11 @@ func TestGetFund(t *testing.T) {
 			SecurityID:           "",
 			FundShortName:        "",
 			NAVDate:              time.Time{},
-			NAV:                  "",
-			PriorNAV:             "",
-			Change:               "",
-			PctChange:            "",
-			Sort:                 "",
+			NAV:                  Decimal{},
+			PriorNAV:             Decimal{},
+			Change:               Decimal{},
+			PctChange:            Decimal{},
+			Sort:                 Decimal{},
 			YtdReturn:            0,
 			YtdReturnMonthly:     0,
 			YtdReturnQuarterly:   0,
@@ -409,7 +567,8 @@ func TestGetFund(t *testing.T) {
 }
 
 func TestGetFundList(t *testing.T) {
-	wantLength := 13
+	useTestServer(t)
+	wantLength := 14
 	name := "Test response list length"
 	t.Run(name, func(t *testing.T) {
 		got, err := GetCommonFundList()
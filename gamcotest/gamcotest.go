@@ -0,0 +1,289 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package gamcotest provides a fake GAMCO API server for hermetic tests.
+//
+// A Server answers the nav_closed_ends endpoint with the bundled example
+// payload, or one supplied by the caller, and can be scripted to misbehave:
+//
+//	srv := gamcotest.NewServer()
+//	defer srv.Close()
+//	srv.Enqueue(gamcotest.Status(http.StatusServiceUnavailable), gamcotest.Latency(time.Second))
+//	c := gamco.NewClient(gamco.WithBaseURL(srv.BaseURL()))
+package gamcotest
+
+import (
+	"bytes"
+	_ "embed" // for the example payload
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// BasePath is the API root the Server mimics; pass BaseURL to
+// gamco.WithBaseURL.
+const BasePath = "/api/v1"
+
+// NavClosedEndsPath is the endpoint the Server answers.
+const NavClosedEndsPath = BasePath + "/nav_closed_ends"
+
+//go:embed example.json
+var exampleJSON []byte
+
+// example is exampleJSON compacted, as the live API sends it.
+var example = func() []byte {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, exampleJSON); err != nil {
+		panic("gamcotest: example.json: " + err.Error())
+	}
+	return buf.Bytes()
+}()
+
+// Example returns a copy of the bundled nav_closed_ends payload, captured
+// from the live API.
+func Example() []byte {
+	b := make([]byte, len(example))
+	copy(b, example)
+	return b
+}
+
+// A Fault makes the Server misbehave for one request. Fields combine: a
+// Fault with both Latency and Status waits, then fails.
+type Fault struct {
+	// Latency delays the response.
+	Latency time.Duration
+
+	// Status, if set, is sent instead of 200 OK with an empty body.
+	Status int
+
+	// Header is added to the response.
+	Header http.Header
+
+	// Truncate sends only the first half of the payload while announcing
+	// its full length, so the client sees the connection drop mid-body.
+	Truncate bool
+
+	// Malformed sends a payload that is not valid JSON.
+	Malformed bool
+
+	// Drift rewrites every fund record before it is sent, to simulate
+	// upstream schema changes.
+	Drift func(record map[string]json.RawMessage)
+}
+
+// Latency returns a Fault delaying the response by d.
+func Latency(d time.Duration) Fault {
+	return Fault{Latency: d}
+}
+
+// Status returns a Fault answering with code.
+func Status(code int) Fault {
+	return Fault{Status: code}
+}
+
+// RetryAfter returns a Fault answering with code and a Retry-After header of
+// d, rounded down to whole seconds.
+func RetryAfter(code int, d time.Duration) Fault {
+	return Fault{
+		Status: code,
+		Header: http.Header{"Retry-After": []string{strconv.Itoa(int(d / time.Second))}},
+	}
+}
+
+// Truncated returns a Fault cutting the payload off halfway.
+func Truncated() Fault {
+	return Fault{Truncate: true}
+}
+
+// MalformedJSON returns a Fault sending invalid JSON.
+func MalformedJSON() Fault {
+	return Fault{Malformed: true}
+}
+
+// RenameField returns a Fault renaming a field in every fund record.
+func RenameField(from, to string) Fault {
+	return Fault{Drift: func(record map[string]json.RawMessage) {
+		if v, ok := record[from]; ok {
+			delete(record, from)
+			record[to] = v
+		}
+	}}
+}
+
+// SetField returns a Fault setting a field to the JSON value raw in every
+// fund record, e.g. to change its type or add a new field.
+func SetField(field string, raw string) Fault {
+	return Fault{Drift: func(record map[string]json.RawMessage) {
+		record[field] = json.RawMessage(raw)
+	}}
+}
+
+// A Request records a request the Server received.
+type Request struct {
+	Method string
+	Path   string
+	Header http.Header
+	Time   time.Time
+}
+
+// A Server is a fake GAMCO API. It is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	payload  []byte
+	faults   []Fault
+	requests []Request
+}
+
+// An Option configures a Server.
+type Option func(*Server)
+
+// WithPayload makes the Server answer with b instead of the example payload.
+func WithPayload(b []byte) Option {
+	return func(s *Server) {
+		s.payload = b
+	}
+}
+
+// WithFunds makes the Server answer with funds encoded as JSON, e.g. a
+// []gamco.Fund or a []map[string]interface{}. It panics if funds cannot be
+// encoded.
+func WithFunds(funds interface{}) Option {
+	b, err := json.Marshal(funds)
+	if err != nil {
+		panic("gamcotest: encoding funds: " + err.Error())
+	}
+	return WithPayload(b)
+}
+
+// WithFaults scripts faults for the Server's first requests, as Enqueue.
+func WithFaults(faults ...Fault) Option {
+	return func(s *Server) {
+		s.faults = append(s.faults, faults...)
+	}
+}
+
+// NewServer starts and returns a Server. The caller should Close it.
+func NewServer(opts ...Option) *Server {
+	s := &Server{payload: example}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+
+	return s
+}
+
+// BaseURL returns the URL to pass to gamco.WithBaseURL.
+func (s *Server) BaseURL() string {
+	return s.URL + BasePath
+}
+
+// Enqueue scripts faults for upcoming requests: each request to the
+// endpoint consumes the next Fault, and requests beyond the script are
+// answered normally.
+func (s *Server) Enqueue(faults ...Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, faults...)
+}
+
+// SetPayload replaces the payload served from now on.
+func (s *Server) SetPayload(b []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.payload = b
+}
+
+// Requests returns the requests received so far, oldest first.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	reqs := make([]Request, len(s.requests))
+	copy(reqs, s.requests)
+	return reqs
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Header: r.Header.Clone(),
+		Time:   time.Now(),
+	})
+	if r.URL.Path != NavClosedEndsPath {
+		s.mu.Unlock()
+		http.NotFound(w, r)
+		return
+	}
+	var f Fault
+	if len(s.faults) > 0 {
+		f, s.faults = s.faults[0], s.faults[1:]
+	}
+	body := s.payload
+	s.mu.Unlock()
+
+	if f.Latency > 0 {
+		t := time.NewTimer(f.Latency)
+		defer t.Stop()
+		select {
+		case <-t.C:
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	for k, v := range f.Header {
+		w.Header()[k] = v
+	}
+	if f.Status != 0 {
+		w.WriteHeader(f.Status)
+		return
+	}
+
+	switch {
+	case f.Malformed:
+		body = []byte(`[{"id": 515, "symbol": "GUT",`)
+	case f.Drift != nil:
+		var err error
+		if body, err = drift(body, f.Drift); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	if f.Truncate {
+		body = body[:len(body)/2]
+	}
+	_, _ = w.Write(body)
+}
+
+// drift applies fn to every record of the payload body.
+func drift(body []byte, fn func(map[string]json.RawMessage)) ([]byte, error) {
+	var records []map[string]json.RawMessage
+	if err := json.Unmarshal(body, &records); err != nil {
+		return nil, err
+	}
+	for _, r := range records {
+		fn(r)
+	}
+	return json.Marshal(records)
+}
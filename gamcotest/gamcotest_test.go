@@ -0,0 +1,207 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamcotest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// get fetches the Server's endpoint and returns the status and body.
+func get(t *testing.T, s *Server) (int, []byte, error) {
+	t.Helper()
+	resp, err := http.Get(s.URL + NavClosedEndsPath)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	return resp.StatusCode, body, err
+}
+
+func TestServerPayload(t *testing.T) {
+	custom := []byte(`[{"id": 1, "symbol": "ABC"}]`)
+	tests := map[string]struct {
+		opts []Option
+		want []byte
+	}{
+		"example": {want: Example()},
+		"payload": {opts: []Option{WithPayload(custom)}, want: custom},
+		"funds": {
+			opts: []Option{WithFunds([]map[string]interface{}{{"id": 1, "symbol": "ABC"}})},
+			want: []byte(`[{"id":1,"symbol":"ABC"}]`),
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := NewServer(tt.opts...)
+			defer s.Close()
+
+			status, got, err := get(t, s)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			if status != http.StatusOK {
+				t.Errorf("%s: got status %v, want %v", name, status, http.StatusOK)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("%s: got %s, want %s", name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServerFaults(t *testing.T) {
+	tests := map[string]struct {
+		fault      Fault
+		wantStatus int
+		wantErr    bool
+		check      func(t *testing.T, body []byte)
+	}{
+		"status": {
+			fault:      Status(http.StatusBadGateway),
+			wantStatus: http.StatusBadGateway,
+		},
+		"truncated": {
+			fault:      Truncated(),
+			wantStatus: http.StatusOK,
+			wantErr:    true,
+		},
+		"malformed": {
+			fault:      MalformedJSON(),
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, body []byte) {
+				if json.Valid(body) {
+					t.Errorf("got valid JSON, want malformed")
+				}
+			},
+		},
+		"rename field": {
+			fault:      RenameField("price", "nav"),
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, body []byte) {
+				var records []map[string]json.RawMessage
+				if err := json.Unmarshal(body, &records); err != nil {
+					t.Fatalf(err.Error())
+				}
+				if _, ok := records[0]["price"]; ok {
+					t.Errorf("got price field, want it renamed")
+				}
+				if _, ok := records[0]["nav"]; !ok {
+					t.Errorf("got no nav field, want price renamed to it")
+				}
+			},
+		},
+		"set field": {
+			fault:      SetField("price", "4.27"),
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, body []byte) {
+				var records []map[string]json.RawMessage
+				if err := json.Unmarshal(body, &records); err != nil {
+					t.Fatalf(err.Error())
+				}
+				if got := string(records[0]["price"]); got != "4.27" {
+					t.Errorf("got price %s, want 4.27", got)
+				}
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := NewServer(WithFaults(tt.fault))
+			defer s.Close()
+
+			status, body, err := get(t, s)
+			if status != tt.wantStatus {
+				t.Errorf("%s: got status %v, want %v", name, status, tt.wantStatus)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("%s: got error %v, want error %v", name, err, tt.wantErr)
+			}
+			if tt.check != nil {
+				tt.check(t, body)
+			}
+
+			// The script is exhausted, so the next request succeeds.
+			status, body, err = get(t, s)
+			if err != nil || status != http.StatusOK || !bytes.Equal(body, Example()) {
+				t.Errorf("%s: request after fault got status %v, error %v", name, status, err)
+			}
+		})
+	}
+}
+
+func TestServerLatency(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.Enqueue(Latency(time.Second))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL+NavClosedEndsPath, nil)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if resp, err := http.DefaultClient.Do(req); err == nil {
+		resp.Body.Close()
+		t.Errorf("got response, want timeout")
+	}
+}
+
+func TestServerRequests(t *testing.T) {
+	s := NewServer(WithFaults(RetryAfter(http.StatusTooManyRequests, 2*time.Second)))
+	defer s.Close()
+
+	resp, err := http.Get(s.URL + NavClosedEndsPath)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	resp.Body.Close()
+	if got := resp.Header.Get("Retry-After"); got != "2" {
+		t.Errorf("got Retry-After %q, want %q", got, "2")
+	}
+
+	req, err := http.NewRequest(http.MethodGet, s.BaseURL()+"/other", nil)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	req.Header.Set("User-Agent", "gamcotest")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("got status %v for unknown path, want %v", resp.StatusCode, http.StatusNotFound)
+	}
+
+	reqs := s.Requests()
+	if len(reqs) != 2 {
+		t.Fatalf("got %v requests, want 2", len(reqs))
+	}
+	if reqs[0].Path != NavClosedEndsPath || reqs[1].Path != BasePath+"/other" {
+		t.Errorf("got paths %q and %q", reqs[0].Path, reqs[1].Path)
+	}
+	if got := reqs[1].Header.Get("User-Agent"); got != "gamcotest" {
+		t.Errorf("got User-Agent %q, want %q", got, "gamcotest")
+	}
+}
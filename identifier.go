@@ -0,0 +1,292 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A CUSIP is a validated 9-character CUSIP, the identifier the feed keys
+// US-listed securities by.
+type CUSIP string
+
+// ParseCUSIP parses s as a CUSIP, checking its check digit. Case and
+// surrounding whitespace are ignored. s may also be a US ISIN, whose CUSIP
+// is returned, so callers can accept either.
+func ParseCUSIP(s string) (CUSIP, error) {
+	id := normalizeID(s)
+	if len(id) == 12 {
+		isin, err := ParseISIN(id)
+		if err != nil {
+			return "", err
+		}
+		c, ok := isin.CUSIP()
+		if !ok {
+			return "", fmt.Errorf("ISIN %q is not a US ISIN", s)
+		}
+		return c, nil
+	}
+
+	if len(id) != 9 {
+		return "", fmt.Errorf("Invalid CUSIP %q: not 9 characters", s)
+	}
+	check, ok := modulus10(id[:8])
+	if !ok {
+		return "", fmt.Errorf("Invalid CUSIP %q: bad character", s)
+	}
+	if id[8] != check {
+		return "", fmt.Errorf("Invalid CUSIP %q: check digit is %c", s, check)
+	}
+	return CUSIP(id), nil
+}
+
+// ISIN returns the US ISIN embedding c.
+func (c CUSIP) ISIN() ISIN {
+	body := "US" + string(c)
+	check, _ := luhn(body)
+	return ISIN(body + string(check))
+}
+
+// Issuer returns the first six characters of c, which identify its issuer.
+func (c CUSIP) Issuer() string {
+	if len(c) < 6 {
+		return string(c)
+	}
+	return string(c[:6])
+}
+
+func (c CUSIP) String() string {
+	return string(c)
+}
+
+// An ISIN is a validated 12-character International Securities
+// Identification Number: a country code, a national identifier and a Luhn
+// check digit.
+type ISIN string
+
+// ParseISIN parses s as an ISIN, checking its check digit. Case and
+// surrounding whitespace are ignored.
+func ParseISIN(s string) (ISIN, error) {
+	id := normalizeID(s)
+	if len(id) != 12 {
+		return "", fmt.Errorf("Invalid ISIN %q: not 12 characters", s)
+	}
+	if !isLetter(id[0]) || !isLetter(id[1]) {
+		return "", fmt.Errorf("Invalid ISIN %q: no country code", s)
+	}
+	check, ok := luhn(id[:11])
+	if !ok {
+		return "", fmt.Errorf("Invalid ISIN %q: bad character", s)
+	}
+	if id[11] != check {
+		return "", fmt.Errorf("Invalid ISIN %q: check digit is %c", s, check)
+	}
+	return ISIN(id), nil
+}
+
+// Country returns i's ISO 3166 country code, e.g. "US".
+func (i ISIN) Country() string {
+	if len(i) < 2 {
+		return ""
+	}
+	return string(i[:2])
+}
+
+// CUSIP returns the CUSIP embedded in a US ISIN, and whether i is one.
+func (i ISIN) CUSIP() (CUSIP, bool) {
+	if i.Country() != "US" || len(i) != 12 {
+		return "", false
+	}
+	c, err := ParseCUSIP(string(i[2:11]))
+	return c, err == nil
+}
+
+func (i ISIN) String() string {
+	return string(i)
+}
+
+// A SEDOL is a validated 7-character SEDOL, the identifier the feed keys
+// London listings by.
+type SEDOL string
+
+// sedolWeights are the weights of a SEDOL's first six characters.
+var sedolWeights = [6]int{1, 3, 1, 7, 3, 9}
+
+// ParseSEDOL parses s as a SEDOL, checking its check digit. Case and
+// surrounding whitespace are ignored.
+func ParseSEDOL(s string) (SEDOL, error) {
+	id := normalizeID(s)
+	if len(id) != 7 {
+		return "", fmt.Errorf("Invalid SEDOL %q: not 7 characters", s)
+	}
+	sum := 0
+	for i, w := range sedolWeights {
+		v, ok := identifierValue(id[i])
+		if !ok || v > 35 {
+			return "", fmt.Errorf("Invalid SEDOL %q: bad character", s)
+		}
+		sum += v * w
+	}
+	if check := byte('0' + (10-sum%10)%10); id[6] != check {
+		return "", fmt.Errorf("Invalid SEDOL %q: check digit is %c", s, check)
+	}
+	return SEDOL(id), nil
+}
+
+func (s SEDOL) String() string {
+	return string(s)
+}
+
+// A FIGI is a validated 12-character Financial Instrument Global
+// Identifier, such as "BBG000BLNNH6".
+type FIGI string
+
+// ParseFIGI parses s as a FIGI, checking its form and check digit. Case and
+// surrounding whitespace are ignored.
+func ParseFIGI(s string) (FIGI, error) {
+	id := normalizeID(s)
+	if len(id) != 12 {
+		return "", fmt.Errorf("Invalid FIGI %q: not 12 characters", s)
+	}
+	if id[2] != 'G' || strings.ContainsAny(id[:11], "AEIOU") {
+		return "", fmt.Errorf("Invalid FIGI %q: not FIGI-shaped", s)
+	}
+	check, ok := modulus10(id[:11])
+	if !ok {
+		return "", fmt.Errorf("Invalid FIGI %q: bad character", s)
+	}
+	if id[11] != check {
+		return "", fmt.Errorf("Invalid FIGI %q: check digit is %c", s, check)
+	}
+	return FIGI(id), nil
+}
+
+func (f FIGI) String() string {
+	return string(f)
+}
+
+// Identifiers are a Fund's validated security identifiers. Each is "" if
+// the Fund has none, or an invalid one.
+type Identifiers struct {
+	CUSIP CUSIP
+	// ISIN is derived from CUSIP.
+	ISIN  ISIN
+	SEDOL SEDOL
+	// FIGI is a placeholder: the feed carries no FIGIs, so it is always ""
+	// unless the caller maps one in, e.g. from OpenFIGI.
+	FIGI FIGI
+}
+
+// Identifiers returns f's validated identifiers, read from Cusip and
+// SecurityID.
+func (f Fund) Identifiers() Identifiers {
+	var ids Identifiers
+	for _, raw := range []string{f.Cusip, f.SecurityID} {
+		switch id := normalizeID(raw); len(id) {
+		case 9:
+			if c, err := ParseCUSIP(id); err == nil && ids.CUSIP == "" {
+				ids.CUSIP, ids.ISIN = c, c.ISIN()
+			}
+		case 7:
+			if s, err := ParseSEDOL(id); err == nil && ids.SEDOL == "" {
+				ids.SEDOL = s
+			}
+		}
+	}
+	return ids
+}
+
+// lookupID normalizes a CUSIP or security ID for lookup, replacing a US
+// ISIN with its CUSIP.
+func lookupID(id string) string {
+	id = normalizeID(id)
+	if len(id) == 12 {
+		if c, err := ParseCUSIP(id); err == nil {
+			return string(c)
+		}
+	}
+	return id
+}
+
+// modulus10 returns the "modulus 10 double add double" check digit of s,
+// used by CUSIPs and FIGIs: every second character's value is doubled and
+// the digits of all values summed. It reports false if s has a character
+// that is not a digit, a letter, '*', '@' or '#'.
+func modulus10(s string) (byte, bool) {
+	sum := 0
+	for i := 0; i < len(s); i++ {
+		v, ok := identifierValue(s[i])
+		if !ok {
+			return 0, false
+		}
+		if i%2 == 1 {
+			v *= 2
+		}
+		sum += v/10 + v%10
+	}
+	return byte('0' + (10-sum%10)%10), true
+}
+
+// luhn returns the Luhn check digit of s, used by ISINs: letters are first
+// expanded to their two-digit values, then every second digit from the
+// right, starting with the last, is doubled and the digits of all values
+// summed. It reports false if s has a character that is not a digit or a
+// letter.
+func luhn(s string) (byte, bool) {
+	var digits []int
+	for i := 0; i < len(s); i++ {
+		v, ok := identifierValue(s[i])
+		if !ok || v > 35 {
+			return 0, false
+		}
+		if v >= 10 {
+			digits = append(digits, v/10)
+		}
+		digits = append(digits, v%10)
+	}
+
+	sum := 0
+	for i := range digits {
+		d := digits[len(digits)-1-i]
+		if i%2 == 0 {
+			d *= 2
+		}
+		sum += d/10 + d%10
+	}
+	return byte('0' + (10-sum%10)%10), true
+}
+
+func isLetter(c byte) bool {
+	return 'A' <= c && c <= 'Z'
+}
+
+// identifierValue returns the value of an identifier character: 0-9 for
+// digits, 10-35 for letters and 36-38 for the CUSIP's '*', '@' and '#'.
+func identifierValue(c byte) (int, bool) {
+	switch {
+	case '0' <= c && c <= '9':
+		return int(c - '0'), true
+	case isLetter(c):
+		return int(c-'A') + 10, true
+	case c == '*':
+		return 36, true
+	case c == '@':
+		return 37, true
+	case c == '#':
+		return 38, true
+	}
+	return 0, false
+}
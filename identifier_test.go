@@ -0,0 +1,155 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import (
+	"testing"
+
+	"github.com/jidicula/go-gamco/gamcotest"
+)
+
+func TestParseCUSIP(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		want     CUSIP
+		wantISIN ISIN
+		wantErr  bool
+	}{
+		"apple":          {input: "037833100", want: "037833100", wantISIN: "US0378331005"},
+		"letters":        {input: "36240A101", want: "36240A101", wantISIN: "US36240A1016"},
+		"lowercase":      {input: " 36250j208 ", want: "36250J208", wantISIN: "US36250J2087"},
+		"isin":           {input: "US3623971013", want: "362397101", wantISIN: "US3623971013"},
+		"bad check":      {input: "362397102", wantErr: true},
+		"short":          {input: "36239710", wantErr: true},
+		"bad character":  {input: "36239710!", wantErr: true},
+		"placeholder":    {input: "-", wantErr: true},
+		"isin bad check": {input: "US3623971014", wantErr: true},
+		"foreign isin":   {input: "GB0002634946", wantErr: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseCUSIP(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("%s: got error %v, want error %t", name, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("%s: got %q, want %q", name, got, tt.want)
+			}
+			if err == nil && got.ISIN() != tt.wantISIN {
+				t.Errorf("%s: got ISIN %q, want %q", name, got.ISIN(), tt.wantISIN)
+			}
+		})
+	}
+}
+
+func TestParseISIN(t *testing.T) {
+	tests := map[string]struct {
+		input       string
+		want        ISIN
+		wantCountry string
+		wantCUSIP   CUSIP
+		wantErr     bool
+	}{
+		"us":          {input: "US0378331005", want: "US0378331005", wantCountry: "US", wantCUSIP: "037833100"},
+		"gb":          {input: "gb0002634946", want: "GB0002634946", wantCountry: "GB"},
+		"letters":     {input: "AU0000XVGZA3", want: "AU0000XVGZA3", wantCountry: "AU"},
+		"bad check":   {input: "US0378331006", wantErr: true},
+		"no country":  {input: "120378331004", wantErr: true},
+		"short":       {input: "US037833100", wantErr: true},
+		"bad letters": {input: "US03783310#5", wantErr: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseISIN(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("%s: got error %v, want error %t", name, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("%s: got %q, want %q", name, got, tt.want)
+			}
+			if got.Country() != tt.wantCountry {
+				t.Errorf("%s: got country %q, want %q", name, got.Country(), tt.wantCountry)
+			}
+			if c, _ := got.CUSIP(); c != tt.wantCUSIP {
+				t.Errorf("%s: got CUSIP %q, want %q", name, c, tt.wantCUSIP)
+			}
+		})
+	}
+}
+
+func TestParseSEDOLAndFIGI(t *testing.T) {
+	tests := map[string]struct {
+		parse   func(string) (string, error)
+		input   string
+		wantErr bool
+	}{
+		"sedol":            {parse: parseSEDOLString, input: "BTLJYS4"},
+		"sedol lowercase":  {parse: parseSEDOLString, input: "bd8p074"},
+		"sedol bad check":  {parse: parseSEDOLString, input: "BD8P075", wantErr: true},
+		"sedol short":      {parse: parseSEDOLString, input: "BD8P07", wantErr: true},
+		"figi":             {parse: parseFIGIString, input: "BBG000BLNNH6"},
+		"figi lowercase":   {parse: parseFIGIString, input: "bbg000b9xry4"},
+		"figi bad check":   {parse: parseFIGIString, input: "BBG000BLNNH7", wantErr: true},
+		"figi vowel":       {parse: parseFIGIString, input: "BBG000BLANH6", wantErr: true},
+		"figi third":       {parse: parseFIGIString, input: "BBX000BLNNH6", wantErr: true},
+		"figi is not isin": {parse: parseFIGIString, input: "US0378331005", wantErr: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := tt.parse(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("%s: got error %v, want error %t", name, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func parseSEDOLString(s string) (string, error) {
+	id, err := ParseSEDOL(s)
+	return string(id), err
+}
+
+func parseFIGIString(s string) (string, error) {
+	id, err := ParseFIGI(s)
+	return string(id), err
+}
+
+func TestFundIdentifiers(t *testing.T) {
+	fl, err := decodeFunds(gamcotest.Example())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[int]Identifiers{
+		502: {CUSIP: "362397101", ISIN: "US3623971013"},
+		523: {SEDOL: "BD8P074"},
+		741: {CUSIP: "36239Q604", ISIN: "US36239Q6044"},
+	}
+	for _, f := range fl {
+		ids := f.Identifiers()
+		if ids.CUSIP == "" && ids.SEDOL == "" {
+			t.Errorf("fund %d: no identifiers from %q, %q", f.ID, f.Cusip, f.SecurityID)
+		}
+		if ids.FIGI != "" {
+			t.Errorf("fund %d: got FIGI %q, want none", f.ID, ids.FIGI)
+		}
+		if w, ok := want[f.ID]; ok && ids != w {
+			t.Errorf("fund %d: got %+v, want %+v", f.ID, ids, w)
+		}
+	}
+}
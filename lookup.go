@@ -0,0 +1,216 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+// snapshotIndexes are a Snapshot's lookup tables, mapping keys to positions
+// in its Funds.
+type snapshotIndexes struct {
+	bySymbol     map[string][]int
+	byCUSIP      map[string][]int
+	bySecurityID map[string][]int
+	byFundCode   map[string][]int
+	byID         map[string][]int
+}
+
+func newSnapshotIndexes(fl []Fund) snapshotIndexes {
+	return snapshotIndexes{
+		bySymbol:     indexFunds(fl, func(f Fund) string { return f.Symbol }),
+		byCUSIP:      indexFunds(fl, func(f Fund) string { return normalizeID(f.Cusip) }),
+		bySecurityID: indexFunds(fl, func(f Fund) string { return normalizeID(f.SecurityID) }),
+		byFundCode:   indexFundCodes(fl),
+		byID:         indexFunds(fl, func(f Fund) string { return strconv.Itoa(f.ID) }),
+	}
+}
+
+// indexFunds maps each non-empty key of fl's Funds to their positions in fl.
+func indexFunds(fl []Fund, key func(Fund) string) map[string][]int {
+	index := make(map[string][]int, len(fl))
+	for i, f := range fl {
+		if k := key(f); k != "" {
+			index[k] = append(index[k], i)
+		}
+	}
+	return index
+}
+
+// indexFundCodes maps every leading run of digits of each Fund's code to
+// the Fund's position in fl, so that FundsByFundCode is a single lookup.
+func indexFundCodes(fl []Fund) map[string][]int {
+	index := make(map[string][]int, 4*len(fl))
+	for i, f := range fl {
+		digits := fundCodeDigits(f.FundCode)
+		for n := 1; n <= len(digits); n++ {
+			index[digits[:n]] = append(index[digits[:n]], i)
+		}
+	}
+	return index
+}
+
+// fundCodeDigits returns code's digits, without its sign.
+func fundCodeDigits(code int) string {
+	return strings.TrimPrefix(strconv.Itoa(code), "-")
+}
+
+// normalizeID uppercases a CUSIP or other security identifier and trims its
+// whitespace. The feed's placeholder for a missing CUSIP, "-", becomes "".
+func normalizeID(id string) string {
+	id = strings.ToUpper(strings.TrimSpace(id))
+	if id == "-" {
+		return ""
+	}
+	return id
+}
+
+// FundByID returns the Fund with the given ID, and whether it was found.
+func (s *Snapshot) FundByID(id int) (Fund, bool) {
+	fl := s.lookup(s.byID, strconv.Itoa(id))
+	if len(fl) == 0 {
+		return Fund{}, false
+	}
+	return fl[0], true
+}
+
+// FundRecordsByCUSIP returns every Fund with the given CUSIP, in feed order.
+// cusip may also be a US ISIN. The match ignores case and surrounding
+// whitespace.
+func (s *Snapshot) FundRecordsByCUSIP(cusip string) []Fund {
+	return s.lookup(s.byCUSIP, lookupID(cusip))
+}
+
+// FundRecordsBySecurityID returns every Fund with the given security ID, in
+// feed order. Security IDs are CUSIPs, or SEDOLs for London listings; a US
+// ISIN matches by its CUSIP. The match ignores case and surrounding
+// whitespace.
+func (s *Snapshot) FundRecordsBySecurityID(id string) []Fund {
+	return s.lookup(s.bySecurityID, lookupID(id))
+}
+
+// FundsByFundCode returns the Funds with fund code code and those whose
+// codes extend it, in feed order, or nil if no Fund has code itself. A
+// fund's share classes and listings extend its code by appending digits:
+// 113 is the Gabelli Utility Trust, and -1131, -1132 and -1135 its NYSE
+// listing, rights and preferred shares. Signs are ignored. A bare prefix
+// such as 11, which is no Fund's code, matches nothing.
+func (s *Snapshot) FundsByFundCode(code int) []Fund {
+	digits := fundCodeDigits(code)
+	fl := s.lookup(s.byFundCode, digits)
+	for _, f := range fl {
+		if fundCodeDigits(f.FundCode) == digits {
+			return fl
+		}
+	}
+	return nil
+}
+
+// GetFundByID returns the Fund with the given ID, or a *NotFoundError if
+// there is none.
+func (c *Client) GetFundByID(id int) (Fund, error) {
+	return c.GetFundByIDContext(context.Background(), id)
+}
+
+// GetFundByIDContext returns the Fund with the given ID, or a *NotFoundError
+// if there is none. If ctx is canceled or its deadline passes before the Fund
+// is decoded, the returned error is a *ContextError.
+func (c *Client) GetFundByIDContext(ctx context.Context, id int) (Fund, error) {
+	s, err := c.SnapshotContext(ctx)
+	if err != nil {
+		return Fund{}, err
+	}
+
+	f, ok := s.FundByID(id)
+	if !ok {
+		return Fund{}, &NotFoundError{Key: "ID", Value: strconv.Itoa(id)}
+	}
+
+	return f, nil
+}
+
+// GetFundByCUSIP returns the Fund with the given CUSIP or US ISIN, or a
+// *NotFoundError if there is none. Of several Funds sharing the CUSIP, such
+// as a fund's NAV and exchange listing, it returns the one picked by the
+// Client's DuplicatePolicy.
+func (c *Client) GetFundByCUSIP(cusip string) (Fund, error) {
+	return c.GetFundByCUSIPContext(context.Background(), cusip)
+}
+
+// GetFundByCUSIPContext returns the Fund with the given CUSIP, or a
+// *NotFoundError if there is none, resolving duplicates as GetFundByCUSIP
+// does. If ctx is canceled or its deadline passes before the Fund is decoded,
+// the returned error is a *ContextError.
+func (c *Client) GetFundByCUSIPContext(ctx context.Context, cusip string) (Fund, error) {
+	return c.getFundBy(ctx, "CUSIP", cusip, (*Snapshot).FundRecordsByCUSIP)
+}
+
+// GetFundBySecurityID returns the Fund with the given security ID, or a
+// *NotFoundError if there is none. Of several Funds sharing the ID, it
+// returns the one picked by the Client's DuplicatePolicy.
+func (c *Client) GetFundBySecurityID(id string) (Fund, error) {
+	return c.GetFundBySecurityIDContext(context.Background(), id)
+}
+
+// GetFundBySecurityIDContext returns the Fund with the given security ID, or a
+// *NotFoundError if there is none, resolving duplicates as GetFundBySecurityID
+// does. If ctx is canceled or its deadline passes before the Fund is decoded,
+// the returned error is a *ContextError.
+func (c *Client) GetFundBySecurityIDContext(ctx context.Context, id string) (Fund, error) {
+	return c.getFundBy(ctx, "security ID", id, (*Snapshot).FundRecordsBySecurityID)
+}
+
+// GetFundsByFundCode returns the Funds with fund code code and those whose
+// codes extend it, or a *NotFoundError if no Fund has code. See
+// Snapshot.FundsByFundCode.
+func (c *Client) GetFundsByFundCode(code int) ([]Fund, error) {
+	return c.GetFundsByFundCodeContext(context.Background(), code)
+}
+
+// GetFundsByFundCodeContext returns the Funds with fund code code and those
+// whose codes extend it, or a *NotFoundError if no Fund has code. If ctx is
+// canceled or its deadline passes before the Funds are decoded, the returned
+// error is a *ContextError.
+func (c *Client) GetFundsByFundCodeContext(ctx context.Context, code int) ([]Fund, error) {
+	s, err := c.SnapshotContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	fl := s.FundsByFundCode(code)
+	if len(fl) == 0 {
+		return nil, &NotFoundError{Key: "fund code", Value: strconv.Itoa(code)}
+	}
+
+	return fl, nil
+}
+
+// getFundBy looks up value with records and resolves duplicates by the
+// Client's DuplicatePolicy. key names the lookup in errors.
+func (c *Client) getFundBy(ctx context.Context, key, value string, records func(*Snapshot, string) []Fund) (Fund, error) {
+	s, err := c.SnapshotContext(ctx)
+	if err != nil {
+		return Fund{}, err
+	}
+
+	fl := records(s, value)
+	if len(fl) == 0 {
+		return Fund{}, &NotFoundError{Key: key, Value: value}
+	}
+
+	return c.duplicates.resolve(value, fl)
+}
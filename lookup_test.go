@@ -0,0 +1,108 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestClientLookups(t *testing.T) {
+	srv := newTestServer(t)
+	c := NewClient(WithBaseURL(srv.BaseURL()), WithCacheTTL(time.Hour))
+
+	tests := map[string]struct {
+		get     func() (Fund, error)
+		wantID  int
+		wantKey string
+	}{
+		"id":                  {get: func() (Fund, error) { return c.GetFundByID(721) }, wantID: 721},
+		"id missing":          {get: func() (Fund, error) { return c.GetFundByID(1) }, wantKey: "ID"},
+		"cusip":               {get: func() (Fund, error) { return c.GetFundByCUSIP("36240A101") }, wantID: 515},
+		"cusip lowercase":     {get: func() (Fund, error) { return c.GetFundByCUSIP(" 36240a101 ") }, wantID: 515},
+		"isin":                {get: func() (Fund, error) { return c.GetFundByCUSIP("US36240A1016") }, wantID: 515},
+		"isin bad check":      {get: func() (Fund, error) { return c.GetFundByCUSIP("US36240A1017") }, wantKey: "CUSIP"},
+		"cusip missing":       {get: func() (Fund, error) { return c.GetFundByCUSIP("000000000") }, wantKey: "CUSIP"},
+		"cusip placeholder":   {get: func() (Fund, error) { return c.GetFundByCUSIP("-") }, wantKey: "CUSIP"},
+		"security id":         {get: func() (Fund, error) { return c.GetFundBySecurityID("362397846") }, wantID: 721},
+		"sedol":               {get: func() (Fund, error) { return c.GetFundBySecurityID("BTLJYS4") }, wantID: 501},
+		"no cusip":            {get: func() (Fund, error) { return c.GetFundBySecurityID("36239Q604") }, wantID: 741},
+		"security id missing": {get: func() (Fund, error) { return c.GetFundBySecurityID("") }, wantKey: "security ID"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := tt.get()
+			if tt.wantKey != "" {
+				var nfErr *NotFoundError
+				if !errors.As(err, &nfErr) || nfErr.Key != tt.wantKey {
+					t.Errorf("%s: got %v, want *NotFoundError for %s", name, err, tt.wantKey)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			if got.ID != tt.wantID {
+				t.Errorf("%s: got ID %v, want %v", name, got.ID, tt.wantID)
+			}
+		})
+	}
+	if got := len(srv.Requests()); got != 1 {
+		t.Errorf("got %v upstream requests, want 1", got)
+	}
+}
+
+func TestClientGetFundsByFundCode(t *testing.T) {
+	srv := newTestServer(t)
+	c := NewClient(WithBaseURL(srv.BaseURL()))
+
+	tests := map[string]struct {
+		code    int
+		wantIDs []int
+		wantErr bool
+	}{
+		"fund":          {code: 113, wantIDs: []int{515, 781, 516, 541, 521}},
+		"negative":      {code: -113, wantIDs: []int{515, 781, 516, 541, 521}},
+		"share class":   {code: -1131, wantIDs: []int{516}},
+		"positive code": {code: 407, wantIDs: []int{513, 514, 701}},
+		"missing":       {code: 999, wantErr: true},
+		"listing group": {code: 1111, wantIDs: []int{502, 721, 554}},
+		"one digit":     {code: 1, wantErr: true},
+		"two digits":    {code: 11, wantErr: true},
+		"prefix of 12x": {code: -12, wantErr: true},
+		"zero":          {code: 0, wantErr: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := c.GetFundsByFundCode(tt.code)
+			if tt.wantErr {
+				if !errors.Is(err, ErrFundNotFound) {
+					t.Errorf("%s: got %v, want %v", name, err, ErrFundNotFound)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			if ids := fundIDs(got); !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("%s: got IDs %v, want %v", name, ids, tt.wantIDs)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Wire formats of the API's date fields.
+const (
+	timestampLayout = "2006-01-02T15:04:05.000Z"
+	usDateLayout    = "01/02/2006"
+)
+
+// A nullString encodes "" as null, as the API does for absent strings.
+type nullString string
+
+func (s nullString) MarshalJSON() ([]byte, error) {
+	if s == "" {
+		return []byte("null"), nil
+	}
+	return json.Marshal(string(s))
+}
+
+// A wireReturn encodes a return, or null if the API sent none.
+type wireReturn struct {
+	v    float64
+	null bool
+}
+
+func (w wireReturn) MarshalJSON() ([]byte, error) {
+	if w.null {
+		return []byte("null"), nil
+	}
+	return json.Marshal(w.v)
+}
+
+// A wireTime encodes a time in layout, or the zero time as null.
+type wireTime struct {
+	t      time.Time
+	layout string
+}
+
+func (w wireTime) MarshalJSON() ([]byte, error) {
+	if w.t.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(w.t.UTC().Format(w.layout))
+}
+
+// fundWire is a Fund in the API's wire format, with fields in the order the
+// API sends them.
+type fundWire struct {
+	ID                   int        `json:"id"`
+	FundCode             int        `json:"fund_code"`
+	SecurityID           nullString `json:"security_id"`
+	FundShortName        nullString `json:"fundshortname"`
+	NAVDate              wireTime   `json:"pricedate"`
+	NAV                  Decimal    `json:"price"`
+	PriorNAV             Decimal    `json:"prior_price"`
+	Change               Decimal    `json:"change"`
+	PctChange            Decimal    `json:"pct_change"`
+	Sort                 Decimal    `json:"sort"`
+	YtdReturn            wireReturn `json:"ytd_return"`
+	YtdReturnMonthly     wireReturn `json:"ytd_return_monthly"`
+	YtdReturnQuarterly   wireReturn `json:"ytd_return_quarterly"`
+	OneYrReturn          wireReturn `json:"one_yr_return"`
+	OneYrReturnMonthly   wireReturn `json:"one_yr_return_monthly"`
+	OneYrReturnQuarterly wireReturn `json:"one_yr_return_quarterly"`
+	ThreeYrAvg           wireReturn `json:"three_yr_avg"`
+	ThreeYrAvgMonthly    wireReturn `json:"three_yr_avg_monthly"`
+	ThreeYrAvgQuarterly  wireReturn `json:"three_yr_avg_quarterly"`
+	FiveYrAvg            wireReturn `json:"five_yr_avg"`
+	FiveYrAvgMonthly     wireReturn `json:"five_yr_avg_monthly"`
+	FiveYrAvgQuarterly   wireReturn `json:"five_yr_avg_quarterly"`
+	TenYrAvg             wireReturn `json:"ten_yr_avg"`
+	TenYrAvgMonthly      wireReturn `json:"ten_yr_avg_monthly"`
+	TenYrAvgQuarterly    wireReturn `json:"ten_yr_avg_quarterly"`
+	InceptAvg            wireReturn `json:"incept_avg"`
+	InceptAvgMonthly     wireReturn `json:"incept_avg_monthly"`
+	InceptAvgQuarterly   wireReturn `json:"incept_avg_quarterly"`
+	Symbol               nullString `json:"symbol"`
+	AssetType            nullString `json:"asset_type"`
+	InceptionDate        wireTime   `json:"inception_date"`
+	LegalName2           nullString `json:"legalname2"`
+	SeriesName           nullString `json:"seriesname"`
+	DisplayName          nullString `json:"displayname"`
+	DisplayName_         nullString `json:"displayname_"`
+	Category             nullString `json:"category"`
+	AnnualReport         nullString `json:"annual_report"`
+	SemiAnnualReport     nullString `json:"semi_annual_report"`
+	Cusip                nullString `json:"cusip"`
+	QuarterlyReport      nullString `json:"quarterly_report"`
+	Prospectus           nullString `json:"prospectus"`
+	Sai                  nullString `json:"sai"`
+	Soi                  nullString `json:"soi"`
+	Factsheet            nullString `json:"factsheet"`
+	Commentary           nullString `json:"commentary"`
+	LastMonthEnd         wireTime   `json:"last_month_end"`
+	LastQtrEnd2          wireTime   `json:"last_qtr_end_2"`
+}
+
+// MarshalJSON encodes f in the API's wire format, so that decoding the
+// result yields f again: pricedate and inception_date as millisecond UTC
+// timestamps, the month and quarter ends as MM/DD/YYYY, prices as strings,
+// asset_type and category as sent, and empty strings, zero dates and
+// returns the API sent as null as null. Extra fields follow the known ones,
+// sorted by name. Warnings are not encoded.
+func (f Fund) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(fundWire{
+		ID:                   f.ID,
+		FundCode:             f.FundCode,
+		SecurityID:           nullString(f.SecurityID),
+		FundShortName:        nullString(f.FundShortName),
+		NAVDate:              wireTime{f.NAVDate, timestampLayout},
+		NAV:                  f.NAV,
+		PriorNAV:             f.PriorNAV,
+		Change:               f.Change,
+		PctChange:            f.PctChange,
+		Sort:                 f.Sort,
+		YtdReturn:            wireReturn{f.YtdReturn, f.nullReturns[YTD][Daily]},
+		YtdReturnMonthly:     wireReturn{f.YtdReturnMonthly, f.nullReturns[YTD][MonthEnd]},
+		YtdReturnQuarterly:   wireReturn{f.YtdReturnQuarterly, f.nullReturns[YTD][QuarterEnd]},
+		OneYrReturn:          wireReturn{f.OneYrReturn, f.nullReturns[OneYear][Daily]},
+		OneYrReturnMonthly:   wireReturn{f.OneYrReturnMonthly, f.nullReturns[OneYear][MonthEnd]},
+		OneYrReturnQuarterly: wireReturn{f.OneYrReturnQuarterly, f.nullReturns[OneYear][QuarterEnd]},
+		ThreeYrAvg:           wireReturn{f.ThreeYrAvg, f.nullReturns[ThreeYear][Daily]},
+		ThreeYrAvgMonthly:    wireReturn{f.ThreeYrAvgMonthly, f.nullReturns[ThreeYear][MonthEnd]},
+		ThreeYrAvgQuarterly:  wireReturn{f.ThreeYrAvgQuarterly, f.nullReturns[ThreeYear][QuarterEnd]},
+		FiveYrAvg:            wireReturn{f.FiveYrAvg, f.nullReturns[FiveYear][Daily]},
+		FiveYrAvgMonthly:     wireReturn{f.FiveYrAvgMonthly, f.nullReturns[FiveYear][MonthEnd]},
+		FiveYrAvgQuarterly:   wireReturn{f.FiveYrAvgQuarterly, f.nullReturns[FiveYear][QuarterEnd]},
+		TenYrAvg:             wireReturn{f.TenYrAvg, f.nullReturns[TenYear][Daily]},
+		TenYrAvgMonthly:      wireReturn{f.TenYrAvgMonthly, f.nullReturns[TenYear][MonthEnd]},
+		TenYrAvgQuarterly:    wireReturn{f.TenYrAvgQuarterly, f.nullReturns[TenYear][QuarterEnd]},
+		InceptAvg:            wireReturn{f.InceptAvg, f.nullReturns[SinceInception][Daily]},
+		InceptAvgMonthly:     wireReturn{f.InceptAvgMonthly, f.nullReturns[SinceInception][MonthEnd]},
+		InceptAvgQuarterly:   wireReturn{f.InceptAvgQuarterly, f.nullReturns[SinceInception][QuarterEnd]},
+		Symbol:               nullString(f.Symbol),
+		AssetType:            nullString(f.wireAssetType()),
+		InceptionDate:        wireTime{f.InceptionDate, timestampLayout},
+		LegalName2:           nullString(f.LegalName2),
+		SeriesName:           nullString(f.SeriesName),
+		DisplayName:          nullString(f.DisplayName),
+		DisplayName_:         nullString(f.DisplayName_),
+		Category:             nullString(f.wireCategory()),
+		AnnualReport:         nullString(f.AnnualReport),
+		SemiAnnualReport:     nullString(f.SemiAnnualReport),
+		Cusip:                nullString(f.Cusip),
+		QuarterlyReport:      nullString(f.QuarterlyReport),
+		Prospectus:           nullString(f.Prospectus),
+		Sai:                  nullString(f.Sai),
+		Soi:                  nullString(f.Soi),
+		Factsheet:            nullString(f.Factsheet),
+		Commentary:           nullString(f.Commentary),
+		LastMonthEnd:         wireTime{f.LastMonthEnd, usDateLayout},
+		LastQtrEnd2:          wireTime{f.LastQtrEnd2, usDateLayout},
+	})
+	if err != nil || f.decoded == nil || len(f.decoded.extra) == 0 {
+		return data, err
+	}
+	return appendExtra(data, f.decoded.extra)
+}
+
+// wireAssetType returns f's asset type as the API sent it, unless AssetType
+// has since been changed to a different one.
+func (f Fund) wireAssetType() string {
+	if ParseAssetType(f.RawAssetType) == f.AssetType {
+		return f.RawAssetType
+	}
+	return string(f.AssetType)
+}
+
+// wireCategory returns f's category as the API sent it, unless Category has
+// since been changed to a different one.
+func (f Fund) wireCategory() string {
+	if ParseCategory(f.RawCategory) == f.Category {
+		return f.RawCategory
+	}
+	return string(f.Category)
+}
+
+// appendExtra appends extra's fields, sorted by name, to the encoded object
+// data. Fields Fund already encodes are skipped.
+func appendExtra(data []byte, extra map[string]json.RawMessage) ([]byte, error) {
+	names := make([]string, 0, len(extra))
+	for name := range extra {
+		if _, known := fundSchemaIndex[name]; !known {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	buf := bytes.NewBuffer(data[:len(data)-1])
+	for _, name := range names {
+		key, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteByte(',')
+		buf.Write(key)
+		buf.WriteByte(':')
+		if err = json.Compact(buf, extra[name]); err != nil {
+			return nil, fmt.Errorf("Invalid extra field %q: %w", name, err)
+		}
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,170 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/jidicula/go-gamco/gamcotest"
+)
+
+func TestFundMarshal(t *testing.T) {
+	f := Fund{}
+	if err := json.Unmarshal([]byte(testGUT), &f); err != nil {
+		t.Fatalf(err.Error())
+	}
+	got, err := json.Marshal(f)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	for _, want := range []string{
+		`"pricedate":"2021-04-01T00:00:00.000Z"`,
+		`"price":"4.27"`,
+		`"last_month_end":"03/31/2021"`,
+		`"last_qtr_end_2":"03/31/2021"`,
+		`"seriesname":null`,
+		`"soi":null`,
+	} {
+		if !bytes.Contains(got, []byte(want)) {
+			t.Errorf("got %s, want it to contain %s", got, want)
+		}
+	}
+	if bytes.Contains(got, []byte("Warnings")) {
+		t.Errorf("got %s, want no Warnings", got)
+	}
+
+	var zero Fund
+	got, err = json.Marshal(zero)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	for _, want := range []string{`"pricedate":null`, `"price":null`, `"last_month_end":null`, `"symbol":null`} {
+		if !bytes.Contains(got, []byte(want)) {
+			t.Errorf("zero Fund: got %s, want it to contain %s", got, want)
+		}
+	}
+}
+
+// TestFundMarshalRoundTrip checks that every Fund in example.json survives
+// decode(encode(f)) == f, and that the encoding matches the upstream record
+// key for key.
+func TestFundMarshalRoundTrip(t *testing.T) {
+	var records []json.RawMessage
+	if err := json.Unmarshal(gamcotest.Example(), &records); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	for i, rec := range records {
+		var f Fund
+		if err := json.Unmarshal(rec, &f); err != nil {
+			t.Fatalf("fund %d: %v", i, err)
+		}
+		enc, err := json.Marshal(f)
+		if err != nil {
+			t.Fatalf("fund %d: %v", i, err)
+		}
+		var again Fund
+		if err := json.Unmarshal(enc, &again); err != nil {
+			t.Fatalf("fund %d: %v", i, err)
+		}
+		if !reflect.DeepEqual(again, f) {
+			t.Errorf("fund %d: got %+v, want %+v", i, again, f)
+		}
+
+		if got, want := keys(t, enc), keys(t, rec); !reflect.DeepEqual(got, want) {
+			t.Errorf("fund %d: got keys %v, want %v", i, got, want)
+		}
+		var gotFields, wantFields map[string]interface{}
+		json.Unmarshal(enc, &gotFields)
+		json.Unmarshal(rec, &wantFields)
+		for k, want := range wantFields {
+			if got := gotFields[k]; !reflect.DeepEqual(got, want) {
+				t.Errorf("fund %d: %s: got %#v, want %#v", i, k, got, want)
+			}
+		}
+	}
+}
+
+// TestFundMarshalExample re-encodes the whole decoded example.json and
+// compares it with the original field by field: strings, nulls and dates
+// byte for byte, and numbers by value.
+func TestFundMarshalExample(t *testing.T) {
+	fl, err := decodeFunds(gamcotest.Example())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	enc, err := json.Marshal(fl)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	var got, want []map[string]json.RawMessage
+	if err = json.Unmarshal(enc, &got); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if err = json.Unmarshal(gamcotest.Example(), &want); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d records, want %d", len(got), len(want))
+	}
+
+	for i := range want {
+		if len(got[i]) != len(want[i]) {
+			t.Errorf("record %d: got %d fields, want %d", i, len(got[i]), len(want[i]))
+		}
+		for k, w := range want[i] {
+			g, ok := got[i][k]
+			if !ok {
+				t.Errorf("record %d: missing %s", i, k)
+				continue
+			}
+			if bytes.Equal(g, w) {
+				continue
+			}
+			var gn, wn float64
+			if json.Unmarshal(g, &gn) == nil && json.Unmarshal(w, &wn) == nil && gn == wn && g[0] != 'n' && w[0] != 'n' {
+				continue
+			}
+			t.Errorf("record %d: %s: got %s, want %s", i, k, g, w)
+		}
+	}
+}
+
+// keys returns the object keys of data in order.
+func keys(t *testing.T, data []byte) []string {
+	t.Helper()
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if _, err := dec.Token(); err != nil {
+		t.Fatalf(err.Error())
+	}
+	var ks []string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		ks = append(ks, tok.(string))
+		var v json.RawMessage
+		if err := dec.Decode(&v); err != nil {
+			t.Fatalf(err.Error())
+		}
+	}
+	return ks
+}
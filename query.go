@@ -0,0 +1,297 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// An Order is the direction of a sort.
+type Order int
+
+// Orders.
+const (
+	Asc Order = iota
+	Desc
+)
+
+// A SortKey is a Fund value a Query can sort by.
+type SortKey struct {
+	name string
+	// cmp compares two Funds' values, returning -1, 0 or +1.
+	cmp func(a, b Fund) int
+	// missing reports whether a Fund lacks the value.
+	missing func(Fund) bool
+}
+
+func (k SortKey) String() string {
+	return k.name
+}
+
+// Sort keys.
+var (
+	ByID     = SortKey{name: "id", cmp: func(a, b Fund) int { return compareInts(a.ID, b.ID) }}
+	BySymbol = SortKey{
+		name:    "symbol",
+		cmp:     func(a, b Fund) int { return compareStrings(a.Symbol, b.Symbol) },
+		missing: func(f Fund) bool { return f.Symbol == "" },
+	}
+	ByNAV           = decimalKey("price", func(f Fund) Decimal { return f.NAV })
+	ByChange        = decimalKey("change", func(f Fund) Decimal { return f.Change })
+	ByPctChange     = decimalKey("pct_change", func(f Fund) Decimal { return f.PctChange })
+	ByNAVDate       = timeKey("pricedate", func(f Fund) time.Time { return f.NAVDate })
+	ByInceptionDate = timeKey("inception_date", func(f Fund) time.Time { return f.InceptionDate })
+
+	ByYtdReturn   = ByReturn(YTD, Daily)
+	ByOneYrReturn = ByReturn(OneYear, Daily)
+	ByThreeYrAvg  = ByReturn(ThreeYear, Daily)
+	ByFiveYrAvg   = ByReturn(FiveYear, Daily)
+	ByTenYrAvg    = ByReturn(TenYear, Daily)
+	ByInceptAvg   = ByReturn(SinceInception, Daily)
+)
+
+// ByReturn returns the SortKey for the return over p measured to b. Funds
+// that did not report the return sort last.
+func ByReturn(p Period, b Basis) SortKey {
+	name := fmt.Sprintf("%s %s return", p, b)
+	if p >= 0 && int(p) < len(returnFields) && b >= 0 && int(b) < len(returnFields[p]) {
+		name = returnFields[p][b]
+	}
+	return SortKey{
+		name: name,
+		cmp: func(x, y Fund) int {
+			return compareFloats(x.Returns().Get(p, b), y.Returns().Get(p, b))
+		},
+		missing: func(f Fund) bool { return !f.Returns().Has(p, b) },
+	}
+}
+
+func decimalKey(name string, value func(Fund) Decimal) SortKey {
+	return SortKey{
+		name:    name,
+		cmp:     func(a, b Fund) int { return value(a).Cmp(value(b)) },
+		missing: func(f Fund) bool { return !value(f).Valid() },
+	}
+}
+
+func timeKey(name string, value func(Fund) time.Time) SortKey {
+	return SortKey{
+		name: name,
+		cmp: func(a, b Fund) int {
+			switch ta, tb := value(a), value(b); {
+			case ta.Before(tb):
+				return -1
+			case ta.After(tb):
+				return 1
+			}
+			return 0
+		},
+		missing: func(f Fund) bool { return value(f).IsZero() },
+	}
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareStrings(a, b string) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+type sortSpec struct {
+	key   SortKey
+	order Order
+}
+
+// A Query selects, orders and limits Funds. Build one with Snapshot.Query or
+// NewQuery and refine it by chaining methods, e.g.
+//
+//	snap.Query().
+//		AssetType(gamco.Equity).
+//		Kind(gamco.Common).
+//		SortBy(gamco.ByOneYrReturn, gamco.Desc).
+//		Limit(10).
+//		Funds()
+//
+// Each method returns a new Query, leaving its receiver unchanged, so a
+// partial Query can be reused. Results are deterministic: unsorted Funds
+// keep feed order, and sorted Funds tying on every key are ordered by ID,
+// then by feed order.
+type Query struct {
+	funds   []Fund
+	filters []func(Fund) bool
+	sorts   []sortSpec
+	limit   int
+}
+
+// NewQuery returns a Query over fl, which it does not modify.
+func NewQuery(fl []Fund) Query {
+	return Query{funds: fl}
+}
+
+// Query returns a Query over the Snapshot's Funds.
+func (s *Snapshot) Query() Query {
+	return NewQuery(s.funds)
+}
+
+// Where keeps only the Funds for which keep returns true.
+func (q Query) Where(keep func(Fund) bool) Query {
+	q.filters = append(q.filters[:len(q.filters):len(q.filters)], keep)
+	return q
+}
+
+// AssetType keeps only Funds of one of the given asset types.
+func (q Query) AssetType(types ...AssetType) Query {
+	return q.Where(func(f Fund) bool {
+		for _, t := range types {
+			if f.AssetType == t {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Category keeps only Funds in one of the given categories.
+func (q Query) Category(categories ...Category) Query {
+	return q.Where(func(f Fund) bool {
+		for _, c := range categories {
+			if f.Category == c {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Kind keeps only Funds whose securities are of one of the given kinds.
+func (q Query) Kind(kinds ...SecurityKind) Query {
+	return q.Where(func(f Fund) bool {
+		kind := f.Security().Kind
+		for _, k := range kinds {
+			if kind == k {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// SortBy orders the Funds by key. Calling it again adds a key that breaks
+// ties left by the earlier ones. Funds lacking the value, such as a null
+// NAV, sort last in either order.
+func (q Query) SortBy(key SortKey, order Order) Query {
+	q.sorts = append(q.sorts[:len(q.sorts):len(q.sorts)], sortSpec{key, order})
+	return q
+}
+
+// Limit keeps at most the first n Funds. n <= 0 means no limit, the
+// default.
+func (q Query) Limit(n int) Query {
+	q.limit = n
+	return q
+}
+
+// Funds runs the Query, returning the selected Funds in order.
+func (q Query) Funds() []Fund {
+	fl := []Fund{}
+	for _, f := range q.funds {
+		if q.keep(f) {
+			fl = append(fl, f)
+		}
+	}
+
+	if len(q.sorts) > 0 {
+		sort.SliceStable(fl, func(i, j int) bool {
+			return q.less(fl[i], fl[j])
+		})
+	}
+	if q.limit > 0 && len(fl) > q.limit {
+		fl = fl[:q.limit]
+	}
+
+	return fl
+}
+
+// First runs the Query, returning its first Fund and whether there was one.
+func (q Query) First() (Fund, bool) {
+	fl := q.Limit(1).Funds()
+	if len(fl) == 0 {
+		return Fund{}, false
+	}
+	return fl[0], true
+}
+
+// Count runs the Query, returning how many Funds it selects.
+func (q Query) Count() int {
+	return len(q.Funds())
+}
+
+func (q Query) keep(f Fund) bool {
+	for _, keep := range q.filters {
+		if !keep(f) {
+			return false
+		}
+	}
+	return true
+}
+
+// less orders a before b by the Query's sort keys, then by ID. Funds are
+// sorted stably, so remaining ties keep feed order.
+func (q Query) less(a, b Fund) bool {
+	for _, s := range q.sorts {
+		if s.key.missing != nil {
+			ma, mb := s.key.missing(a), s.key.missing(b)
+			if ma != mb {
+				return mb
+			}
+			if ma {
+				continue
+			}
+		}
+		c := s.key.cmp(a, b)
+		if s.order == Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c < 0
+		}
+	}
+	return a.ID < b.ID
+}
@@ -0,0 +1,166 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jidicula/go-gamco/gamcotest"
+)
+
+func TestQuery(t *testing.T) {
+	s, err := newSnapshot(Payload{Body: gamcotest.Example()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	merger := s.Query().Category(MergerArbitrage)
+
+	tests := map[string]struct {
+		query Query
+		want  []int
+	}{
+		"feed order": {
+			query: merger,
+			want:  []int{523, 524, 539, 561, 550},
+		},
+		"common by one-year return": {
+			query: s.Query().AssetType(Equity).Category(MergerArbitrage).Kind(Common).SortBy(ByOneYrReturn, Desc),
+			want:  []int{539, 550},
+		},
+		"ties by id": {
+			query: merger.SortBy(ByOneYrReturn, Desc),
+			want:  []int{539, 523, 550, 524, 561},
+		},
+		"null returns last": {
+			query: merger.SortBy(ByOneYrReturn, Asc),
+			want:  []int{550, 523, 539, 524, 561},
+		},
+		"limit": {
+			query: merger.SortBy(ByOneYrReturn, Desc).Limit(2),
+			want:  []int{539, 523},
+		},
+		"second key": {
+			query: merger.SortBy(BySymbol, Asc).SortBy(ByNAV, Desc),
+			want:  []int{550, 539, 561, 523, 524},
+		},
+		"return basis": {
+			query: merger.Kind(Common).SortBy(ByReturn(FiveYear, QuarterEnd), Asc),
+			want:  []int{550, 539},
+		},
+		"where": {
+			query: merger.Where(func(f Fund) bool { return f.NAV.Cmp(MustParseDecimal("10")) > 0 }),
+			want:  []int{561, 550},
+		},
+		"several kinds": {
+			query: merger.Kind(Preferred, ForeignListing).SortBy(ByID, Desc),
+			want:  []int{561, 524, 523},
+		},
+		"no match": {
+			query: merger.Kind(Rights),
+			want:  []int{},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := fundIDs(tt.query.Funds()); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s: got %v, want %v", name, got, tt.want)
+			}
+			if got := tt.query.Count(); got != len(tt.want) {
+				t.Errorf("%s: got count %d, want %d", name, got, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestQueryMissingValuesSortLast(t *testing.T) {
+	fl := []Fund{
+		{ID: 1, NAV: MustParseDecimal("2")},
+		{ID: 2},
+		{ID: 3, NAV: MustParseDecimal("3")},
+		{ID: 4, NAV: MustParseDecimal("1")},
+	}
+
+	tests := map[string]struct {
+		order Order
+		want  []int
+	}{
+		"asc":  {order: Asc, want: []int{4, 1, 3, 2}},
+		"desc": {order: Desc, want: []int{3, 1, 4, 2}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := fundIDs(NewQuery(fl).SortBy(ByNAV, tt.order).Funds())
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s: got %v, want %v", name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryReuse(t *testing.T) {
+	fl := []Fund{{ID: 3}, {ID: 1}, {ID: 2}}
+	base := NewQuery(fl).Where(func(f Fund) bool { return f.ID > 1 })
+	sorted := base.SortBy(ByID, Asc)
+	limited := base.Limit(1)
+	_ = base.Where(func(f Fund) bool { return false })
+
+	if got, want := fundIDs(base.Funds()), []int{3, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("base: got %v, want %v", got, want)
+	}
+	if got, want := fundIDs(sorted.Funds()), []int{2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sorted: got %v, want %v", got, want)
+	}
+	if got, want := fundIDs(limited.Funds()), []int{3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("limited: got %v, want %v", got, want)
+	}
+	if got, want := fundIDs(fl), []int{3, 1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("input: got %v, want %v", got, want)
+	}
+
+	if f, ok := sorted.First(); !ok || f.ID != 2 {
+		t.Errorf("first: got %d, %t, want 2, true", f.ID, ok)
+	}
+	if _, ok := base.Where(func(Fund) bool { return false }).First(); ok {
+		t.Error("first of empty: got a Fund")
+	}
+}
+
+func TestQueryNullReturnsSortLast(t *testing.T) {
+	s, err := newSnapshot(Payload{Body: gamcotest.Example()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, order := range []Order{Asc, Desc} {
+		fl := s.Query().SortBy(ByOneYrReturn, order).Funds()
+		reported := 0
+		for _, f := range fl {
+			if f.Returns().Has(OneYear, Daily) {
+				reported++
+			}
+		}
+		if reported == len(fl) {
+			t.Fatalf("order %v: got no Funds without a 1Y return", order)
+		}
+		for i, f := range fl {
+			if got, want := f.Returns().Has(OneYear, Daily), i < reported; got != want {
+				t.Errorf("order %v: position %d (fund %d): got reported %t, want %t", order, i, f.ID, got, want)
+			}
+		}
+	}
+}
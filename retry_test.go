@@ -0,0 +1,210 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jidicula/go-gamco/gamcotest"
+)
+
+// testRetryPolicy retries quickly so tests stay fast.
+var testRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: time.Millisecond,
+	MaxBackoff:     5 * time.Millisecond,
+	Multiplier:     2,
+}
+
+func TestGetDataRetry(t *testing.T) {
+	tests := map[string]struct {
+		faults    []gamcotest.Fault
+		policy    RetryPolicy
+		wantCalls int
+		wantErr   bool
+	}{
+		"success": {
+			policy:    testRetryPolicy,
+			wantCalls: 1,
+		},
+		"recovers from 502 and 503": {
+			faults:    []gamcotest.Fault{gamcotest.Status(http.StatusBadGateway), gamcotest.Status(http.StatusServiceUnavailable)},
+			policy:    testRetryPolicy,
+			wantCalls: 3,
+		},
+		"honors Retry-After": {
+			faults:    []gamcotest.Fault{gamcotest.RetryAfter(http.StatusTooManyRequests, 0)},
+			policy:    testRetryPolicy,
+			wantCalls: 2,
+		},
+		"recovers from truncated body": {
+			faults:    []gamcotest.Fault{gamcotest.Truncated()},
+			policy:    testRetryPolicy,
+			wantCalls: 2,
+		},
+		"gives up after max attempts": {
+			faults: []gamcotest.Fault{
+				gamcotest.Status(http.StatusServiceUnavailable),
+				gamcotest.Status(http.StatusServiceUnavailable),
+				gamcotest.Status(http.StatusServiceUnavailable),
+			},
+			policy:    testRetryPolicy,
+			wantCalls: 3,
+			wantErr:   true,
+		},
+		"does not retry client errors": {
+			faults:    []gamcotest.Fault{gamcotest.Status(http.StatusNotFound)},
+			policy:    testRetryPolicy,
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		"no retry": {
+			faults:    []gamcotest.Fault{gamcotest.Status(http.StatusServiceUnavailable)},
+			policy:    NoRetry,
+			wantCalls: 1,
+			wantErr:   true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			srv := newTestServer(t, gamcotest.WithFaults(tt.faults...))
+			c := NewClient(WithBaseURL(srv.BaseURL()), WithRetryPolicy(tt.policy))
+
+			_, err := c.getData(context.Background(), nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("%s: got error %v, want error %v", name, err, tt.wantErr)
+			}
+			if got := len(srv.Requests()); got != tt.wantCalls {
+				t.Errorf("%s: got %v calls, want %v", name, got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestGetDataRetryTransportError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := ts.URL
+	ts.Close()
+
+	c := NewClient(WithBaseURL(url), WithRetryPolicy(testRetryPolicy))
+	if _, err := c.getData(context.Background(), nil); err == nil {
+		t.Errorf("got nil error, want transport error")
+	}
+}
+
+func TestGetDataRetryCanceled(t *testing.T) {
+	srv := newTestServer(t, gamcotest.WithFaults(gamcotest.Status(http.StatusServiceUnavailable)))
+	policy := RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Hour}
+	c := NewClient(WithBaseURL(srv.BaseURL()), WithRetryPolicy(policy))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := c.getData(ctx, nil)
+	if _, ok := err.(*ContextError); !ok {
+		t.Errorf("got %v, want *ContextError", err)
+	}
+	if got := len(srv.Requests()); got != 1 {
+		t.Errorf("got %v calls, want 1", got)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	tests := map[string]struct {
+		policy     RetryPolicy
+		attempt    int
+		retryAfter time.Duration
+		want       time.Duration
+	}{
+		"first": {
+			policy:  RetryPolicy{InitialBackoff: time.Second, Multiplier: 2},
+			attempt: 1,
+			want:    time.Second,
+		},
+		"exponential": {
+			policy:  RetryPolicy{InitialBackoff: time.Second, Multiplier: 2},
+			attempt: 4,
+			want:    8 * time.Second,
+		},
+		"capped": {
+			policy:  RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 3 * time.Second, Multiplier: 2},
+			attempt: 4,
+			want:    3 * time.Second,
+		},
+		"constant": {
+			policy:  RetryPolicy{InitialBackoff: time.Second},
+			attempt: 3,
+			want:    time.Second,
+		},
+		"retry after": {
+			policy:     RetryPolicy{InitialBackoff: time.Second, Multiplier: 2},
+			attempt:    1,
+			retryAfter: 7 * time.Second,
+			want:       7 * time.Second,
+		},
+		"retry after capped": {
+			policy:     RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second},
+			attempt:    1,
+			retryAfter: time.Minute,
+			want:       5 * time.Second,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := tt.policy.backoff(tt.attempt, tt.retryAfter); got != tt.want {
+				t.Errorf("%s: got %v, want %v", name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyJitter(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: time.Second, Jitter: 0.5}
+	for i := 0; i < 100; i++ {
+		got := p.backoff(1, 0)
+		if got < 500*time.Millisecond || got > 1500*time.Millisecond {
+			t.Fatalf("got %v, want within 500ms-1.5s", got)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+	tests := map[string]struct {
+		header string
+		want   time.Duration
+	}{
+		"empty":     {header: "", want: 0},
+		"seconds":   {header: "120", want: 2 * time.Minute},
+		"negative":  {header: "-1", want: 0},
+		"http date": {header: "Thu, 01 Apr 2021 00:00:30 GMT", want: 30 * time.Second},
+		"past date": {header: "Wed, 31 Mar 2021 00:00:00 GMT", want: 0},
+		"malformed": {header: "soon", want: 0},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := parseRetryAfter(tt.header, now); got != tt.want {
+				t.Errorf("%s: got %v, want %v", name, got, tt.want)
+			}
+		})
+	}
+}
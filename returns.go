@@ -0,0 +1,162 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import (
+	"fmt"
+	"time"
+)
+
+// A Period is the span a return covers.
+type Period int
+
+// Return periods. Returns over three years or more are annualized.
+const (
+	YTD Period = iota
+	OneYear
+	ThreeYear
+	FiveYear
+	TenYear
+	SinceInception
+)
+
+var periodNames = [...]string{
+	YTD:            "YTD",
+	OneYear:        "1Y",
+	ThreeYear:      "3Y",
+	FiveYear:       "5Y",
+	TenYear:        "10Y",
+	SinceInception: "inception",
+}
+
+// Periods returns every Period, shortest first.
+func Periods() []Period {
+	return []Period{YTD, OneYear, ThreeYear, FiveYear, TenYear, SinceInception}
+}
+
+// String returns p's name, e.g. "5Y".
+func (p Period) String() string {
+	if p < 0 || int(p) >= len(periodNames) {
+		return fmt.Sprintf("Period(%d)", int(p))
+	}
+	return periodNames[p]
+}
+
+// A Basis is the date a return is measured to.
+type Basis int
+
+// Return bases.
+const (
+	// Daily returns are measured to the Fund's NAVDate.
+	Daily Basis = iota
+	// MonthEnd returns are measured to its LastMonthEnd.
+	MonthEnd
+	// QuarterEnd returns are measured to its LastQtrEnd2.
+	QuarterEnd
+)
+
+var basisNames = [...]string{
+	Daily:      "daily",
+	MonthEnd:   "month-end",
+	QuarterEnd: "quarter-end",
+}
+
+// Bases returns every Basis.
+func Bases() []Basis {
+	return []Basis{Daily, MonthEnd, QuarterEnd}
+}
+
+// String returns b's name, e.g. "month-end".
+func (b Basis) String() string {
+	if b < 0 || int(b) >= len(basisNames) {
+		return fmt.Sprintf("Basis(%d)", int(b))
+	}
+	return basisNames[b]
+}
+
+// returnFields are the upstream names of the return fields, by Period and
+// Basis.
+var returnFields = [len(periodNames)][len(basisNames)]string{
+	YTD:            {"ytd_return", "ytd_return_monthly", "ytd_return_quarterly"},
+	OneYear:        {"one_yr_return", "one_yr_return_monthly", "one_yr_return_quarterly"},
+	ThreeYear:      {"three_yr_avg", "three_yr_avg_monthly", "three_yr_avg_quarterly"},
+	FiveYear:       {"five_yr_avg", "five_yr_avg_monthly", "five_yr_avg_quarterly"},
+	TenYear:        {"ten_yr_avg", "ten_yr_avg_monthly", "ten_yr_avg_quarterly"},
+	SinceInception: {"incept_avg", "incept_avg_monthly", "incept_avg_quarterly"},
+}
+
+// Returns are a Fund's total returns, as fractions (0.05 is 5%), indexed by
+// Period and Basis.
+type Returns struct {
+	values [len(periodNames)][len(basisNames)]float64
+	null   [len(periodNames)][len(basisNames)]bool
+	asOf   [len(basisNames)]time.Time
+}
+
+// Returns gathers f's return fields into Returns.
+func (f Fund) Returns() Returns {
+	return Returns{
+		values: [len(periodNames)][len(basisNames)]float64{
+			YTD:            {f.YtdReturn, f.YtdReturnMonthly, f.YtdReturnQuarterly},
+			OneYear:        {f.OneYrReturn, f.OneYrReturnMonthly, f.OneYrReturnQuarterly},
+			ThreeYear:      {f.ThreeYrAvg, f.ThreeYrAvgMonthly, f.ThreeYrAvgQuarterly},
+			FiveYear:       {f.FiveYrAvg, f.FiveYrAvgMonthly, f.FiveYrAvgQuarterly},
+			TenYear:        {f.TenYrAvg, f.TenYrAvgMonthly, f.TenYrAvgQuarterly},
+			SinceInception: {f.InceptAvg, f.InceptAvgMonthly, f.InceptAvgQuarterly},
+		},
+		null: f.nullReturns,
+		asOf: [len(basisNames)]time.Time{
+			Daily:      f.NAVDate,
+			MonthEnd:   f.LastMonthEnd,
+			QuarterEnd: f.LastQtrEnd2,
+		},
+	}
+}
+
+// Get returns the return over p measured to b, or 0 if p or b is unknown or
+// the Fund did not report it.
+func (r Returns) Get(p Period, b Basis) float64 {
+	if p < 0 || int(p) >= len(r.values) || b < 0 || int(b) >= len(r.asOf) {
+		return 0
+	}
+	return r.values[p][b]
+}
+
+// Has reports whether the Fund reported the return over p measured to b,
+// i.e. the API did not send it as null.
+func (r Returns) Has(p Period, b Basis) bool {
+	if p < 0 || int(p) >= len(r.values) || b < 0 || int(b) >= len(r.asOf) {
+		return false
+	}
+	return !r.null[p][b]
+}
+
+// AsOf returns the date returns on basis b are measured to, or the zero time
+// if b is unknown or the Fund did not say.
+func (r Returns) AsOf(b Basis) time.Time {
+	if b < 0 || int(b) >= len(r.asOf) {
+		return time.Time{}
+	}
+	return r.asOf[b]
+}
+
+// Each calls fn with every return, by Period and then Basis.
+func (r Returns) Each(fn func(p Period, b Basis, value float64)) {
+	for _, p := range Periods() {
+		for _, b := range Bases() {
+			fn(p, b, r.values[p][b])
+		}
+	}
+}
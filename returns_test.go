@@ -0,0 +1,157 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestFundReturns(t *testing.T) {
+	navDate := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+	monthEnd := time.Date(2021, 3, 31, 0, 0, 0, 0, time.UTC)
+	quarterEnd := time.Date(2021, 3, 31, 0, 0, 0, 0, time.UTC)
+	f := Fund{
+		NAVDate:              navDate,
+		LastMonthEnd:         monthEnd,
+		LastQtrEnd2:          quarterEnd,
+		YtdReturn:            0.01,
+		YtdReturnMonthly:     0.02,
+		YtdReturnQuarterly:   0.03,
+		OneYrReturn:          0.11,
+		OneYrReturnMonthly:   0.12,
+		OneYrReturnQuarterly: 0.13,
+		ThreeYrAvg:           0.31,
+		ThreeYrAvgMonthly:    0.32,
+		ThreeYrAvgQuarterly:  0.33,
+		FiveYrAvg:            0.51,
+		FiveYrAvgMonthly:     0.52,
+		FiveYrAvgQuarterly:   0.53,
+		TenYrAvg:             1.01,
+		TenYrAvgMonthly:      1.02,
+		TenYrAvgQuarterly:    1.03,
+		InceptAvg:            9.01,
+		InceptAvgMonthly:     9.02,
+		InceptAvgQuarterly:   9.03,
+	}
+	r := f.Returns()
+
+	tests := map[string]struct {
+		period Period
+		basis  Basis
+		want   float64
+	}{
+		"ytd daily":             {period: YTD, basis: Daily, want: 0.01},
+		"1y month-end":          {period: OneYear, basis: MonthEnd, want: 0.12},
+		"3y quarter-end":        {period: ThreeYear, basis: QuarterEnd, want: 0.33},
+		"5y quarter-end":        {period: FiveYear, basis: QuarterEnd, want: 0.53},
+		"10y daily":             {period: TenYear, basis: Daily, want: 1.01},
+		"inception month-end":   {period: SinceInception, basis: MonthEnd, want: 9.02},
+		"inception quarter-end": {period: SinceInception, basis: QuarterEnd, want: 9.03},
+		"unknown period":        {period: Period(6), basis: Daily},
+		"negative period":       {period: Period(-1), basis: Daily},
+		"unknown basis":         {period: YTD, basis: Basis(3)},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := r.Get(tt.period, tt.basis); got != tt.want {
+				t.Errorf("%s: got %v, want %v", name, got, tt.want)
+			}
+		})
+	}
+
+	for b, want := range map[Basis]time.Time{Daily: navDate, MonthEnd: monthEnd, QuarterEnd: quarterEnd, Basis(9): {}} {
+		if got := r.AsOf(b); !got.Equal(want) {
+			t.Errorf("AsOf(%v): got %v, want %v", b, got, want)
+		}
+	}
+}
+
+func TestReturnsEach(t *testing.T) {
+	f := Fund{YtdReturn: 1, OneYrReturnMonthly: 2, InceptAvgQuarterly: 3}
+
+	var visited []string
+	sum := 0.0
+	f.Returns().Each(func(p Period, b Basis, value float64) {
+		visited = append(visited, p.String()+" "+b.String())
+		sum += value
+	})
+	if len(visited) != 18 {
+		t.Fatalf("got %v returns, want 18", len(visited))
+	}
+	if visited[0] != "YTD daily" || visited[4] != "1Y month-end" || visited[17] != "inception quarter-end" {
+		t.Errorf("got order %v", visited)
+	}
+	if sum != 6 {
+		t.Errorf("got sum %v, want 6", sum)
+	}
+}
+
+func TestPeriodBasisString(t *testing.T) {
+	tests := map[fmt.Stringer]string{
+		YTD:            "YTD",
+		OneYear:        "1Y",
+		ThreeYear:      "3Y",
+		FiveYear:       "5Y",
+		TenYear:        "10Y",
+		SinceInception: "inception",
+		Period(7):      "Period(7)",
+		Daily:          "daily",
+		MonthEnd:       "month-end",
+		QuarterEnd:     "quarter-end",
+		Basis(-1):      "Basis(-1)",
+	}
+
+	for v, want := range tests {
+		if got := v.String(); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+}
+
+func TestReturnsHas(t *testing.T) {
+	var f Fund
+	if err := json.Unmarshal([]byte(`{"ytd_return": 0.05, "ytd_return_monthly": null, "one_yr_return": 0}`), &f); err != nil {
+		t.Fatalf(err.Error())
+	}
+	r := f.Returns()
+
+	tests := map[string]struct {
+		period Period
+		basis  Basis
+		want   bool
+	}{
+		"reported":      {period: YTD, basis: Daily, want: true},
+		"reported zero": {period: OneYear, basis: Daily, want: true},
+		"null":          {period: YTD, basis: MonthEnd},
+		"absent":        {period: TenYear, basis: QuarterEnd},
+		"unknown":       {period: Period(9), basis: Daily},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := r.Has(tt.period, tt.basis); got != tt.want {
+				t.Errorf("%s: got %t, want %t", name, got, tt.want)
+			}
+		})
+	}
+
+	if !(Fund{}).Returns().Has(YTD, Daily) {
+		t.Error("Fund literal: got no YTD return, want one")
+	}
+}
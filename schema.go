@@ -0,0 +1,305 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// WithStrictDecoding makes the Client check every record of each payload
+// against the fields and JSON types the API is known to send. Departures are
+// reported by Snapshot.Schema rather than failing the fetch, and fields
+// Fund does not know are returned by Fund.Extra. Use it to notice upstream
+// schema drift, such as a renamed field, that would otherwise be silently
+// dropped. A record that no longer decodes at all still fails the fetch
+// with a *DecodeError; CheckSchema can report on such a payload.
+func WithStrictDecoding() Option {
+	return func(c *Client) {
+		c.strict = true
+	}
+}
+
+// A SchemaIssueKind is a way a record can depart from the expected schema.
+type SchemaIssueKind int
+
+// Kinds of SchemaIssue.
+const (
+	// UnknownField is a field Fund does not know.
+	UnknownField SchemaIssueKind = iota
+	// MissingField is an expected field the record lacks.
+	MissingField
+	// TypeChanged is a field of an unexpected JSON type, such as a price
+	// sent as a number rather than a string.
+	TypeChanged
+)
+
+var schemaIssueKindNames = [...]string{
+	UnknownField: "unknown field",
+	MissingField: "missing field",
+	TypeChanged:  "type changed",
+}
+
+// String returns k's name, e.g. "unknown field".
+func (k SchemaIssueKind) String() string {
+	if k < 0 || int(k) >= len(schemaIssueKindNames) {
+		return fmt.Sprintf("SchemaIssueKind(%d)", int(k))
+	}
+	return schemaIssueKindNames[k]
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (k SchemaIssueKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// A SchemaIssue is one departure of a record from the expected schema.
+type SchemaIssue struct {
+	// Index is the record's position in the payload.
+	Index  int
+	Symbol string
+	Field  string
+	Kind   SchemaIssueKind
+	// Want and Got are the expected and actual JSON types of a field
+	// whose type changed, e.g. "string" and "number".
+	Want, Got string
+}
+
+func (i SchemaIssue) String() string {
+	msg := fmt.Sprintf("%s %s", i.Kind, i.Field)
+	if i.Kind == TypeChanged {
+		msg += fmt.Sprintf(": got %s, want %s", i.Got, i.Want)
+	}
+	if i.Symbol != "" {
+		msg += " of " + i.Symbol
+	}
+	return msg + fmt.Sprintf(" (fund %d)", i.Index)
+}
+
+// A SchemaReport lists how a payload departed from the expected schema.
+type SchemaReport struct {
+	// Issues are in feed order and, within a record, in field order, with
+	// unknown fields last, sorted by name.
+	Issues []SchemaIssue
+}
+
+// Clean reports whether the payload matched the expected schema.
+func (r *SchemaReport) Clean() bool {
+	return len(r.Issues) == 0
+}
+
+// Fields returns the distinct fields with issues of kind k, sorted.
+func (r *SchemaReport) Fields(k SchemaIssueKind) []string {
+	seen := make(map[string]bool)
+	var fields []string
+	for _, i := range r.Issues {
+		if i.Kind == k && !seen[i.Field] {
+			seen[i.Field] = true
+			fields = append(fields, i.Field)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// A schemaField is a field the API is known to send, with its JSON type
+// and whether it may be null.
+type schemaField struct {
+	name     string
+	kind     string
+	nullable bool
+}
+
+// fundSchema is the expected schema of a fund record, in the API's field
+// order.
+var fundSchema = []schemaField{
+	{"id", "number", false},
+	{"fund_code", "number", false},
+	{"security_id", "string", false},
+	{"fundshortname", "string", false},
+	{"pricedate", "string", false},
+	{"price", "string", false},
+	{"prior_price", "string", false},
+	{"change", "string", false},
+	{"pct_change", "string", false},
+	{"sort", "string", false},
+	{"ytd_return", "number", true},
+	{"ytd_return_monthly", "number", true},
+	{"ytd_return_quarterly", "number", true},
+	{"one_yr_return", "number", true},
+	{"one_yr_return_monthly", "number", true},
+	{"one_yr_return_quarterly", "number", true},
+	{"three_yr_avg", "number", true},
+	{"three_yr_avg_monthly", "number", true},
+	{"three_yr_avg_quarterly", "number", true},
+	{"five_yr_avg", "number", true},
+	{"five_yr_avg_monthly", "number", true},
+	{"five_yr_avg_quarterly", "number", true},
+	{"ten_yr_avg", "number", true},
+	{"ten_yr_avg_monthly", "number", true},
+	{"ten_yr_avg_quarterly", "number", true},
+	{"incept_avg", "number", true},
+	{"incept_avg_monthly", "number", true},
+	{"incept_avg_quarterly", "number", true},
+	{"symbol", "string", true},
+	{"asset_type", "string", true},
+	{"inception_date", "string", true},
+	{"legalname2", "string", false},
+	{"seriesname", "string", true},
+	{"displayname", "string", true},
+	{"displayname_", "string", false},
+	{"category", "string", false},
+	{"annual_report", "string", true},
+	{"semi_annual_report", "string", true},
+	{"cusip", "string", false},
+	{"quarterly_report", "string", true},
+	{"prospectus", "string", true},
+	{"sai", "string", true},
+	{"soi", "string", true},
+	{"factsheet", "string", true},
+	{"commentary", "string", true},
+	{"last_month_end", "string", true},
+	{"last_qtr_end_2", "string", true},
+}
+
+// fundSchemaIndex maps each expected field's name to its position in
+// fundSchema.
+var fundSchemaIndex = func() map[string]int {
+	index := make(map[string]int, len(fundSchema))
+	for i, f := range fundSchema {
+		index[f.name] = i
+	}
+	return index
+}()
+
+// CheckSchema checks every record of a nav_closed_ends payload against the
+// expected schema without decoding it into Funds, so it reports drift even
+// in a payload that no longer decodes. It fails only if data is not a JSON
+// array of objects.
+func CheckSchema(data []byte) (*SchemaReport, error) {
+	var records []json.RawMessage
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, newDecodeError(-1, err)
+	}
+
+	report := &SchemaReport{}
+	for i, r := range records {
+		issues, _, err := checkRecord(i, r)
+		if err != nil {
+			return nil, err
+		}
+		report.Issues = append(report.Issues, issues...)
+	}
+
+	return report, nil
+}
+
+// decodeFundsStrict decodes a payload as decodeFunds does, also checking
+// each record against the expected schema and keeping its unknown fields
+// for Fund.Extra.
+func decodeFundsStrict(data []byte) ([]Fund, *SchemaReport, error) {
+	var records []json.RawMessage
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, nil, newDecodeError(-1, err)
+	}
+
+	fl := make([]Fund, len(records))
+	report := &SchemaReport{}
+	for i, r := range records {
+		issues, extra, err := checkRecord(i, r)
+		if err != nil {
+			return nil, nil, err
+		}
+		report.Issues = append(report.Issues, issues...)
+
+		if err = decodeRecord(i, r, &fl[i]); err != nil {
+			return nil, nil, err
+		}
+		if extra != nil {
+			fl[i].decoding().extra = extra
+		}
+	}
+
+	return fl, report, nil
+}
+
+// checkRecord checks the raw fund record at index against fundSchema,
+// returning its issues and its unknown fields, or nil if it has none.
+func checkRecord(index int, r json.RawMessage) ([]SchemaIssue, map[string]json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(r, &fields); err != nil {
+		de := newDecodeError(index, err)
+		de.Symbol = recordSymbol(r)
+		return nil, nil, de
+	}
+
+	var symbol string
+	_ = json.Unmarshal(fields["symbol"], &symbol)
+	issue := func(field string, k SchemaIssueKind) SchemaIssue {
+		return SchemaIssue{Index: index, Symbol: symbol, Field: field, Kind: k}
+	}
+
+	var issues []SchemaIssue
+	for _, sf := range fundSchema {
+		raw, ok := fields[sf.name]
+		if !ok {
+			issues = append(issues, issue(sf.name, MissingField))
+			continue
+		}
+		got := valueKind(raw)
+		if got == sf.kind || got == "null" && sf.nullable {
+			continue
+		}
+		i := issue(sf.name, TypeChanged)
+		i.Want, i.Got = sf.kind, got
+		issues = append(issues, i)
+	}
+
+	var extra map[string]json.RawMessage
+	var unknown []string
+	for name, raw := range fields {
+		if _, known := fundSchemaIndex[name]; known {
+			continue
+		}
+		if extra == nil {
+			extra = make(map[string]json.RawMessage)
+		}
+		extra[name] = raw
+		unknown = append(unknown, name)
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		issues = append(issues, issue(name, UnknownField))
+	}
+
+	return issues, extra, nil
+}
+
+// valueKind names the JSON type of the value raw, e.g. "string".
+func valueKind(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return "null"
+	}
+	switch raw[0] {
+	case '"':
+		return "string"
+	case 'n':
+		return "null"
+	case '{', '[', 't', 'f':
+		return jsonKind(raw[0])
+	}
+	return "number"
+}
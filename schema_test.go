@@ -0,0 +1,230 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/jidicula/go-gamco/gamcotest"
+)
+
+// driftExample returns the example payload with fn applied to every record.
+func driftExample(t *testing.T, fn func(record map[string]json.RawMessage)) []byte {
+	t.Helper()
+	var records []map[string]json.RawMessage
+	if err := json.Unmarshal(gamcotest.Example(), &records); err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range records {
+		fn(r)
+	}
+	b, err := json.Marshal(records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestCheckSchema(t *testing.T) {
+	fl, err := decodeFunds(gamcotest.Example())
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := len(fl)
+	tests := map[string]struct {
+		drift       func(record map[string]json.RawMessage)
+		wantUnknown []string
+		wantMissing []string
+		wantChanged []string
+		wantIssues  int
+	}{
+		"example": {
+			drift: func(map[string]json.RawMessage) {},
+		},
+		"null dates": {
+			drift: func(r map[string]json.RawMessage) {
+				r["last_month_end"] = json.RawMessage("null")
+				r["last_qtr_end_2"] = json.RawMessage("null")
+			},
+		},
+		"renamed": {
+			drift: func(r map[string]json.RawMessage) {
+				r["nav"] = r["price"]
+				delete(r, "price")
+			},
+			wantUnknown: []string{"nav"},
+			wantMissing: []string{"price"},
+			wantIssues:  2 * n,
+		},
+		"price as number": {
+			drift: func(r map[string]json.RawMessage) {
+				r["price"] = bytes.Trim(r["price"], `"`)
+			},
+			wantChanged: []string{"price"},
+			wantIssues:  n,
+		},
+		"null price": {
+			drift: func(r map[string]json.RawMessage) {
+				r["price"] = json.RawMessage("null")
+			},
+			wantChanged: []string{"price"},
+			wantIssues:  n,
+		},
+		"added fields": {
+			drift: func(r map[string]json.RawMessage) {
+				r["isin"] = json.RawMessage(`"US0000000000"`)
+				r["flags"] = json.RawMessage(`[]`)
+			},
+			wantUnknown: []string{"flags", "isin"},
+			wantIssues:  2 * n,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			report, err := CheckSchema(driftExample(t, tt.drift))
+			if err != nil {
+				t.Fatalf("%s: got error %v", name, err)
+			}
+			if got := len(report.Issues); got != tt.wantIssues {
+				t.Errorf("%s: got %d issues, want %d: %v", name, got, tt.wantIssues, report.Issues)
+			}
+			if got := report.Clean(); got != (tt.wantIssues == 0) {
+				t.Errorf("%s: got Clean %t, want %t", name, got, tt.wantIssues == 0)
+			}
+			for k, want := range map[SchemaIssueKind][]string{
+				UnknownField: tt.wantUnknown,
+				MissingField: tt.wantMissing,
+				TypeChanged:  tt.wantChanged,
+			} {
+				if got := report.Fields(k); !reflect.DeepEqual(got, want) {
+					t.Errorf("%s: got %s %v, want %v", name, k, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckSchemaIssue(t *testing.T) {
+	payload := []byte(`[{"id": 1, "symbol": "GAB", "price": 6.87, "sort": "1", "extra": true}]`)
+	report, err := CheckSchema(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []SchemaIssue
+	for _, i := range report.Issues {
+		if i.Kind != MissingField {
+			got = append(got, i)
+		}
+	}
+	want := []SchemaIssue{
+		{Index: 0, Symbol: "GAB", Field: "price", Kind: TypeChanged, Want: "string", Got: "number"},
+		{Index: 0, Symbol: "GAB", Field: "extra", Kind: UnknownField},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if s, want := got[0].String(), "type changed price: got number, want string of GAB (fund 0)"; s != want {
+		t.Errorf("got %q, want %q", s, want)
+	}
+
+	for name, payload := range map[string]string{
+		"not an array":   `{"id": 1}`,
+		"not an object":  `[1]`,
+		"malformed json": `[{"id": 1`,
+	} {
+		if _, err := CheckSchema([]byte(payload)); err == nil {
+			t.Errorf("%s: got no error", name)
+		}
+	}
+}
+
+func TestStrictDecoding(t *testing.T) {
+	srv := newTestServer(t, gamcotest.WithFaults(
+		gamcotest.RenameField("sort", "sort_order"),
+		gamcotest.RenameField("sort", "sort_order"),
+	))
+
+	lax, err := NewClient(WithBaseURL(srv.BaseURL())).Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lax.Schema() != nil {
+		t.Errorf("lax: got schema report %v, want none", lax.Schema())
+	}
+	if f := lax.Funds()[0]; f.Extra() != nil {
+		t.Errorf("lax: got Extra %v, want none", f.Extra())
+	}
+
+	strict, err := NewClient(WithBaseURL(srv.BaseURL()), WithStrictDecoding()).Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := strict.Schema()
+	if report == nil {
+		t.Fatal("strict: got no schema report")
+	}
+	if got, want := report.Fields(UnknownField), []string{"sort_order"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("strict: got unknown %v, want %v", got, want)
+	}
+	if got, want := report.Fields(MissingField), []string{"sort"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("strict: got missing %v, want %v", got, want)
+	}
+
+	f := strict.Funds()[0]
+	if _, ok := f.Extra()["sort_order"]; !ok {
+		t.Fatalf("strict: got Extra %v, want sort_order", f.Extra())
+	}
+
+	// Extra fields survive re-encoding.
+	b, err := json.Marshal(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]json.RawMessage
+	if err = json.Unmarshal(b, &fields); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(fields["sort_order"]), string(f.Extra()["sort_order"]); got != want {
+		t.Errorf("re-encoded sort_order: got %s, want %s", got, want)
+	}
+}
+
+func TestFundMarshalExtra(t *testing.T) {
+	f := Fund{ID: 1, decoded: &fundDecoding{extra: map[string]json.RawMessage{
+		"zeta":  json.RawMessage(`{ "a": 1 }`),
+		"alpha": json.RawMessage(`"x"`),
+		"id":    json.RawMessage(`2`),
+	}}}
+	b, err := json.Marshal(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte(`,"last_qtr_end_2":null,"alpha":"x","zeta":{"a":1}}`); !bytes.HasSuffix(b, want) {
+		t.Errorf("got %s, want suffix %s", b, want)
+	}
+	if !bytes.HasPrefix(b, []byte(`{"id":1,`)) {
+		t.Errorf("got %s, want known id kept", b)
+	}
+
+	f.decoded.extra["bad"] = json.RawMessage(`{`)
+	if _, err = json.Marshal(f); err == nil {
+		t.Error("invalid extra: got no error")
+	}
+}
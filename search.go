@@ -0,0 +1,277 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// A SearchResult is a Fund matching a search, with its score.
+type SearchResult struct {
+	Fund Fund
+	// Score is how well the Fund matched, from 0 (exclusive) to 1 when the
+	// query is exactly one of the Fund's names.
+	Score float64
+}
+
+// A SearchOption configures a search.
+type SearchOption func(*searchOptions)
+
+type searchOptions struct {
+	maxResults int
+	maxEdits   int
+}
+
+// WithMaxResults limits a search to its n best results. n <= 0 means no
+// limit, the default.
+func WithMaxResults(n int) SearchOption {
+	return func(o *searchOptions) {
+		o.maxResults = n
+	}
+}
+
+// WithMaxEdits sets how many typos, as edit distance, a search tolerates in
+// each query word; the default is 2. Short words tolerate fewer: none up to
+// 3 letters and at most 1 up to 6.
+func WithMaxEdits(n int) SearchOption {
+	return func(o *searchOptions) {
+		o.maxEdits = n
+	}
+}
+
+// Search returns the Snapshot's Funds whose names match query, best first.
+// It searches DisplayName, DisplayName_, LegalName2, FundShortName and
+// SeriesName, ignoring case, diacritics, punctuation and word order. Each
+// query word scores its best match among a Fund's words: 1 if exact, less
+// for a prefix or a near miss within the edit distance allowed. A Fund's
+// score is mostly the average over the query's words, and partly how much of
+// its best-matching name the query covers. Funds with equal scores are
+// ordered by ID.
+func (s *Snapshot) Search(query string, opts ...SearchOption) []SearchResult {
+	o := searchOptions{maxEdits: 2}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	words := searchTokens(query)
+	if len(words) == 0 {
+		return nil
+	}
+
+	var rs []SearchResult
+	for _, f := range s.funds {
+		if score := searchScore(words, f, o.maxEdits); score > 0 {
+			rs = append(rs, SearchResult{Fund: f, Score: score})
+		}
+	}
+
+	sort.SliceStable(rs, func(i, j int) bool {
+		if rs[i].Score != rs[j].Score {
+			return rs[i].Score > rs[j].Score
+		}
+		return rs[i].Fund.ID < rs[j].Fund.ID
+	})
+	if o.maxResults > 0 && len(rs) > o.maxResults {
+		rs = rs[:o.maxResults]
+	}
+
+	return rs
+}
+
+// Search returns the Funds whose names match query, best first. See
+// Snapshot.Search.
+func (c *Client) Search(query string, opts ...SearchOption) ([]SearchResult, error) {
+	return c.SearchContext(context.Background(), query, opts...)
+}
+
+// SearchContext returns the Funds whose names match query, best first. See
+// Snapshot.Search. If ctx is canceled or its deadline passes before the
+// Funds are decoded, the returned error is a *ContextError.
+func (c *Client) SearchContext(ctx context.Context, query string, opts ...SearchOption) ([]SearchResult, error) {
+	s, err := c.SnapshotContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Search(query, opts...), nil
+}
+
+// searchScore scores how well query words match f's names: mostly by how
+// well each query word matches, and partly by how much of the best-matching
+// name the query covers, so that shorter names rank first.
+func searchScore(words []string, f Fund, maxEdits int) float64 {
+	var names [][]string
+	for _, name := range []string{f.DisplayName, f.DisplayName_, f.LegalName2, f.FundShortName, f.SeriesName} {
+		if ws := searchTokens(name); len(ws) > 0 {
+			names = append(names, ws)
+		}
+	}
+
+	recall, precision := 0.0, 0.0
+	for _, w := range words {
+		best := 0.0
+		for _, name := range names {
+			for _, n := range name {
+				if score := wordScore(w, n, maxEdits); score > best {
+					best = score
+				}
+			}
+		}
+		recall += best
+	}
+	if recall == 0 {
+		return 0
+	}
+	for _, name := range names {
+		matched := 0
+		for _, n := range name {
+			for _, w := range words {
+				if wordScore(w, n, maxEdits) > 0 {
+					matched++
+					break
+				}
+			}
+		}
+		if p := float64(matched) / float64(len(name)); p > precision {
+			precision = p
+		}
+	}
+
+	return 0.9*recall/float64(len(words)) + 0.1*precision
+}
+
+// wordScore scores how well query word w matches name word n.
+func wordScore(w, n string, maxEdits int) float64 {
+	switch {
+	case w == n:
+		return 1
+	case len(w) >= 3 && strings.HasPrefix(n, w):
+		return 0.9
+	}
+
+	allowed := maxEdits
+	if l := len([]rune(w)); l <= 3 {
+		allowed = 0
+	} else if l <= 6 && allowed > 1 {
+		allowed = 1
+	}
+	if allowed <= 0 {
+		return 0
+	}
+	d := editDistance(w, n, allowed)
+	if d > allowed {
+		return 0
+	}
+
+	return 0.8 * (1 - float64(d)/float64(len([]rune(w))))
+}
+
+// editDistance returns the Levenshtein distance between a and b, or limit+1
+// if it exceeds limit.
+func editDistance(a, b string, limit int) int {
+	ra, rb := []rune(a), []rune(b)
+	if diff := len(ra) - len(rb); diff > limit || -diff > limit {
+		return limit + 1
+	}
+
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if cur[j] < rowMin {
+				rowMin = cur[j]
+			}
+		}
+		if rowMin > limit {
+			return limit + 1
+		}
+		prev, cur = cur, prev
+	}
+	if prev[len(rb)] > limit {
+		return limit + 1
+	}
+
+	return prev[len(rb)]
+}
+
+func minInt(n int, ns ...int) int {
+	for _, m := range ns {
+		if m < n {
+			n = m
+		}
+	}
+	return n
+}
+
+// htmlTag matches markup in names, e.g. the <SUP> of
+// "Healthcare & Wellness<SUP>Rx</SUP> Trust".
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// searchTokens splits text into lowercase words without diacritics.
+func searchTokens(text string) []string {
+	text = htmlTag.ReplaceAllString(text, " ")
+	return strings.FieldsFunc(strings.Map(foldRune, text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// diacritics maps accented Latin letters to their base letters.
+var diacritics = map[rune]rune{}
+
+func init() {
+	for base, accented := range map[rune]string{
+		'a': "àáâãäåāăą",
+		'c': "çćĉċč",
+		'd': "ďđ",
+		'e': "èéêëēĕėęě",
+		'g': "ĝğġģ",
+		'i': "ìíîïĩīĭįı",
+		'l': "ĺļľŀł",
+		'n': "ñńņňŉ",
+		'o': "òóôõöøōŏő",
+		'r': "ŕŗř",
+		's': "śŝşš",
+		't': "ţťŧ",
+		'u': "ùúûüũūŭůűų",
+		'y': "ýÿŷ",
+		'z': "źżž",
+	} {
+		for _, r := range accented {
+			diacritics[r] = base
+		}
+	}
+}
+
+// foldRune lowercases r and strips its diacritics.
+func foldRune(r rune) rune {
+	r = unicode.ToLower(r)
+	if base, ok := diacritics[r]; ok {
+		return base
+	}
+	return r
+}
@@ -0,0 +1,129 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import (
+	"testing"
+
+	"github.com/jidicula/go-gamco/gamcotest"
+)
+
+func TestSnapshotSearch(t *testing.T) {
+	s, err := newSnapshot(Payload{Body: gamcotest.Example()})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	tests := map[string]struct {
+		query   string
+		wantTop int
+		wantNil bool
+	}{
+		"words":       {query: "utility trust", wantTop: 515},
+		"word order":  {query: "trust utility", wantTop: 515},
+		"across name": {query: "gabelli gold", wantTop: 532},
+		"typo":        {query: "utlity trsut", wantTop: 515},
+		"diacritics":  {query: "GABÉLLI GÖLD", wantTop: 532},
+		"punctuation": {query: "merger-plus+", wantTop: 523},
+		"markup":      {query: "wellness rx trust", wantTop: 544},
+		"prefix":      {query: "ellsw", wantTop: 519},
+		"series name": {query: "convertible income securities", wantTop: 513},
+		"no match":    {query: "xyzzy", wantNil: true},
+		"short typo":  {query: "gxb", wantNil: true},
+		"empty":       {query: "  ", wantNil: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := s.Search(tt.query)
+			if tt.wantNil {
+				if got != nil {
+					t.Errorf("%s: got %v results, want none", name, len(got))
+				}
+				return
+			}
+			if len(got) == 0 {
+				t.Fatalf("%s: got no results", name)
+			}
+			if got[0].Fund.ID != tt.wantTop {
+				t.Errorf("%s: got top result %v (%s), want %v", name, got[0].Fund.ID, got[0].Fund.DisplayName, tt.wantTop)
+			}
+			for i := 1; i < len(got); i++ {
+				prev, cur := got[i-1], got[i]
+				if cur.Score > prev.Score || cur.Score == prev.Score && cur.Fund.ID < prev.Fund.ID {
+					t.Errorf("%s: results %d and %d out of order", name, i-1, i)
+				}
+				if cur.Score <= 0 || cur.Score > 1 {
+					t.Errorf("%s: got score %v, want (0, 1]", name, cur.Score)
+				}
+			}
+		})
+	}
+}
+
+func TestSnapshotSearchOptions(t *testing.T) {
+	s, err := newSnapshot(Payload{Body: gamcotest.Example()})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if got := s.Search("gabelli", WithMaxResults(3)); len(got) != 3 {
+		t.Errorf("got %v results, want 3", len(got))
+	}
+	if got, all := s.Search("gabelli", WithMaxResults(0)), s.Search("gabelli"); len(got) != len(all) {
+		t.Errorf("got %v results, want all %v", len(got), len(all))
+	}
+	if got := s.Search("utlity", WithMaxEdits(0)); got != nil {
+		t.Errorf("got %v results without typo tolerance, want none", len(got))
+	}
+	if got := s.Search("utlity", WithMaxEdits(1)); len(got) == 0 {
+		t.Errorf("got no results, want typo tolerated")
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		limit int
+		want  int
+	}{
+		{a: "utility", b: "utility", limit: 2, want: 0},
+		{a: "utlity", b: "utility", limit: 2, want: 1},
+		{a: "trsut", b: "trust", limit: 2, want: 2},
+		{a: "gold", b: "global", limit: 2, want: 3},
+		{a: "a", b: "abcdef", limit: 2, want: 3},
+		{a: "", b: "ab", limit: 2, want: 2},
+		{a: "müller", b: "muller", limit: 1, want: 1},
+	}
+
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b, tt.limit); got != tt.want {
+			t.Errorf("editDistance(%q, %q, %d): got %v, want %v", tt.a, tt.b, tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestClientSearch(t *testing.T) {
+	srv := newTestServer(t)
+	c := NewClient(WithBaseURL(srv.BaseURL()))
+
+	got, err := c.Search("utility trust", WithMaxResults(1))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if len(got) != 1 || got[0].Fund.Symbol != "GUT" {
+		t.Errorf("got %+v, want GUT", got)
+	}
+}
@@ -0,0 +1,223 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import (
+	"context"
+	"time"
+)
+
+// A Snapshot is one parsed copy of the nav_closed_ends payload. It is
+// immutable and safe for concurrent use.
+type Snapshot struct {
+	funds []Fund
+	snapshotIndexes
+	fetchedAt time.Time
+	stale     bool
+	schema    *SchemaReport
+}
+
+// newSnapshot parses p into a Snapshot.
+func newSnapshot(p Payload) (*Snapshot, error) {
+	fl, err := decodeFunds(p.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Snapshot{
+		funds:           fl,
+		snapshotIndexes: newSnapshotIndexes(fl),
+		fetchedAt:       p.FetchedAt,
+		stale:           p.Stale,
+	}, nil
+}
+
+// newStrictSnapshot parses p into a Snapshot as newSnapshot does, checking
+// each record against the expected schema and keeping unknown fields.
+func newStrictSnapshot(p Payload) (*Snapshot, error) {
+	fl, report, err := decodeFundsStrict(p.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Snapshot{
+		funds:           fl,
+		snapshotIndexes: newSnapshotIndexes(fl),
+		fetchedAt:       p.FetchedAt,
+		stale:           p.Stale,
+		schema:          report,
+	}, nil
+}
+
+// FetchedAt returns when the Snapshot's payload was fetched from its
+// DataSource. For a payload served from the disk cache, that is when it was
+// last fetched or revalidated.
+func (s *Snapshot) FetchedAt() time.Time {
+	return s.fetchedAt
+}
+
+// Stale reports whether the Snapshot's DataSource served an outdated copy
+// because the real one could not be reached. See WithDiskCache.
+func (s *Snapshot) Stale() bool {
+	return s.stale
+}
+
+// Funds returns every Fund in the Snapshot in feed order.
+func (s *Snapshot) Funds() []Fund {
+	fl := make([]Fund, len(s.funds))
+	copy(fl, s.funds)
+	return fl
+}
+
+// Schema returns the report of how the Snapshot's payload departed from
+// the expected schema, or nil if it was not decoded strictly. See
+// WithStrictDecoding.
+func (s *Snapshot) Schema() *SchemaReport {
+	return s.schema
+}
+
+// Warnings returns every Warning raised while decoding the Snapshot's Funds,
+// in feed order.
+func (s *Snapshot) Warnings() []Warning {
+	var ws []Warning
+	for _, f := range s.funds {
+		ws = append(ws, f.Warnings()...)
+	}
+	return ws
+}
+
+// Fund returns the symbol's matching Fund, and whether it was found. Of
+// several Funds sharing the symbol, it returns the one with the lowest ID.
+func (s *Snapshot) Fund(symbol string) (Fund, bool) {
+	fl := s.FundRecords(symbol)
+	if len(fl) == 0 {
+		return Fund{}, false
+	}
+	f, _ := LowestID.resolve(symbol, fl)
+	return f, true
+}
+
+// lookup returns the Funds index lists under key, in feed order.
+func (s *Snapshot) lookup(index map[string][]int, key string) []Fund {
+	idx := index[key]
+	if len(idx) == 0 {
+		return nil
+	}
+	fl := make([]Fund, len(idx))
+	for i, j := range idx {
+		fl[i] = s.funds[j]
+	}
+	return fl
+}
+
+// CommonFunds returns the Snapshot's common GAMCO Funds.
+func (s *Snapshot) CommonFunds() []Fund {
+	// filter only common stock
+	return s.Query().
+		Kind(Common).
+		Where(func(f Fund) bool { return f.AnnualReport != "" }).
+		Funds()
+}
+
+// fresh reports whether the Snapshot is younger than ttl at now.
+func (s *Snapshot) fresh(ttl time.Duration, now time.Time) bool {
+	return s != nil && ttl > 0 && now.Sub(s.fetchedAt) < ttl
+}
+
+// WithCacheTTL makes the Client keep its last Snapshot for ttl and answer
+// every lookup from it until it expires. A non-positive ttl, the default,
+// fetches on every call. NAVs are published once a day, so a TTL of minutes
+// or hours is usually safe.
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(c *Client) {
+		c.cacheTTL = ttl
+	}
+}
+
+// Snapshot returns the cached Snapshot if it is still fresh, and fetches a
+// new one otherwise.
+func (c *Client) Snapshot() (*Snapshot, error) {
+	return c.SnapshotContext(context.Background())
+}
+
+// SnapshotContext returns the cached Snapshot if it is still fresh, and
+// fetches a new one otherwise. If ctx is canceled or its deadline passes
+// before the Snapshot is decoded, the returned error is a *ContextError.
+func (c *Client) SnapshotContext(ctx context.Context) (*Snapshot, error) {
+	c.mu.Lock()
+	s := c.snapshot
+	c.mu.Unlock()
+
+	if s.fresh(c.cacheTTL, c.now()) {
+		return s, nil
+	}
+
+	return c.RefreshContext(ctx)
+}
+
+// Refresh fetches a new Snapshot regardless of the cached one's age, and
+// caches it.
+func (c *Client) Refresh() (*Snapshot, error) {
+	return c.RefreshContext(context.Background())
+}
+
+// RefreshContext fetches a new Snapshot regardless of the cached one's age,
+// and caches it. Concurrent callers share a single in-flight fetch. If ctx is
+// canceled or its deadline passes before the Snapshot is decoded, the
+// returned error is a *ContextError and the cache is left untouched.
+func (c *Client) RefreshContext(ctx context.Context) (*Snapshot, error) {
+	if err := contextErr(ctx, nil); err != nil {
+		return nil, err
+	}
+
+	f := c.joinFlight()
+	select {
+	case <-f.done:
+		return f.snap, f.err
+	case <-ctx.Done():
+		c.leaveFlight(f)
+		return nil, &ContextError{Err: ctx.Err()}
+	}
+}
+
+// fetchSnapshot fetches and parses a new Snapshot.
+func (c *Client) fetchSnapshot(ctx context.Context) (*Snapshot, error) {
+	p, err := c.source.Payload(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = contextErr(ctx, nil); err != nil {
+		return nil, err
+	}
+	if p.FetchedAt.IsZero() {
+		p.FetchedAt = c.now()
+	}
+	if c.strict {
+		return newStrictSnapshot(p)
+	}
+	return newSnapshot(p)
+}
+
+// Invalidate drops the cached Snapshot, so the next lookup fetches anew. A
+// fetch already running still answers its callers, but its Snapshot is not
+// cached and later lookups do not join it.
+func (c *Client) Invalidate() {
+	c.mu.Lock()
+	c.snapshot = nil
+	c.inflight = nil
+	c.generation++
+	c.mu.Unlock()
+}
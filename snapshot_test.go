@@ -0,0 +1,197 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jidicula/go-gamco/gamcotest"
+)
+
+// A fakeClock is a manually advanced clock for cache tests.
+type fakeClock struct {
+	t time.Time
+}
+
+func (fc *fakeClock) now() time.Time { return fc.t }
+
+func TestSnapshotCache(t *testing.T) {
+	symbols := []string{"GUT", "GGT", "GAB", "GDV", "GRX"}
+	tests := map[string]struct {
+		ttl       time.Duration
+		advance   time.Duration
+		wantCalls int
+	}{
+		"disabled":  {ttl: 0, wantCalls: 2 * len(symbols)},
+		"fresh":     {ttl: time.Hour, advance: 59 * time.Minute, wantCalls: 1},
+		"expired":   {ttl: time.Hour, advance: time.Hour, wantCalls: 2},
+		"long ttl":  {ttl: 24 * time.Hour, advance: 12 * time.Hour, wantCalls: 1},
+		"short ttl": {ttl: time.Minute, advance: 2 * time.Minute, wantCalls: 2},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			srv := newTestServer(t)
+			clock := &fakeClock{t: time.Date(2021, 4, 1, 18, 0, 0, 0, time.UTC)}
+			c := NewClient(WithBaseURL(srv.BaseURL()), WithCacheTTL(tt.ttl))
+			c.now = clock.now
+
+			for _, sym := range symbols {
+				if _, err := c.GetFund(sym); err != nil {
+					t.Fatalf(err.Error())
+				}
+			}
+			clock.t = clock.t.Add(tt.advance)
+			for _, sym := range symbols {
+				if _, err := c.GetFund(sym); err != nil {
+					t.Fatalf(err.Error())
+				}
+			}
+
+			if got := len(srv.Requests()); got != tt.wantCalls {
+				t.Errorf("%s: got %v calls, want %v", name, got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestSnapshotSharedAcrossLookups(t *testing.T) {
+	srv := newTestServer(t)
+	c := NewClient(WithBaseURL(srv.BaseURL()), WithCacheTTL(time.Hour))
+
+	if _, err := c.GetCommonFundList(); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if _, err := c.GetFund("GUT"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if got := len(srv.Requests()); got != 1 {
+		t.Errorf("got %v calls, want 1", got)
+	}
+}
+
+func TestRefreshAndInvalidate(t *testing.T) {
+	srv := newTestServer(t)
+	c := NewClient(WithBaseURL(srv.BaseURL()), WithCacheTTL(time.Hour))
+
+	first, err := c.Snapshot()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	refreshed, err := c.Refresh()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if refreshed == first {
+		t.Errorf("Refresh returned the cached Snapshot")
+	}
+	if got, _ := c.Snapshot(); got != refreshed {
+		t.Errorf("Snapshot after Refresh did not return the refreshed Snapshot")
+	}
+
+	c.Invalidate()
+	if got, _ := c.Snapshot(); got == refreshed {
+		t.Errorf("Snapshot after Invalidate returned the dropped Snapshot")
+	}
+
+	if got := len(srv.Requests()); got != 3 {
+		t.Errorf("got %v calls, want 3", got)
+	}
+}
+
+func TestInvalidateDuringFetch(t *testing.T) {
+	srv := newTestServer(t)
+	srv.Enqueue(gamcotest.Latency(200 * time.Millisecond))
+	c := NewClient(WithBaseURL(srv.BaseURL()), WithCacheTTL(time.Hour))
+
+	type result struct {
+		s   *Snapshot
+		err error
+	}
+	slow := make(chan result, 1)
+	go func() {
+		s, err := c.Refresh()
+		slow <- result{s, err}
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(srv.Requests()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the slow fetch to start")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	c.Invalidate()
+
+	r := <-slow
+	if r.err != nil {
+		t.Fatalf(r.err.Error())
+	}
+	got, err := c.Snapshot()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if got == r.s {
+		t.Errorf("Snapshot after Invalidate returned the Snapshot of a fetch started before it")
+	}
+	if n := len(srv.Requests()); n != 2 {
+		t.Errorf("got %v calls, want 2", n)
+	}
+}
+
+func TestRefreshFailureKeepsCache(t *testing.T) {
+	srv := newTestServer(t)
+	c := NewClient(WithBaseURL(srv.BaseURL()), WithCacheTTL(time.Hour))
+
+	cached, err := c.Snapshot()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.RefreshContext(ctx); err == nil {
+		t.Fatalf("got nil error, want canceled refresh")
+	}
+
+	if got, _ := c.Snapshot(); got != cached {
+		t.Errorf("failed Refresh replaced the cached Snapshot")
+	}
+}
+
+func TestSnapshotFunds(t *testing.T) {
+	srv := newTestServer(t)
+	c := NewClient(WithBaseURL(srv.BaseURL()))
+
+	s, err := c.Snapshot()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	wantLength := 53
+	fl := s.Funds()
+	if len(fl) != wantLength {
+		t.Fatalf("got %v Funds, want %v", len(fl), wantLength)
+	}
+
+	// Callers must not be able to mutate the shared Snapshot.
+	fl[0].Symbol = "MUTATED"
+	if s.Funds()[0].Symbol == "MUTATED" {
+		t.Errorf("Funds returned the Snapshot's backing slice")
+	}
+}
@@ -0,0 +1,163 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// A Payload is a raw nav_closed_ends body: a JSON array of funds.
+type Payload struct {
+	Body []byte
+
+	// FetchedAt is when Body was obtained. If zero, the Client uses the
+	// time it received the Payload.
+	FetchedAt time.Time
+
+	// ModTime is when Body was last modified at its source, if known, such
+	// as a FileSource's file modification time.
+	ModTime time.Time
+
+	// Stale reports that Body is an outdated copy served because the real
+	// source could not be reached.
+	Stale bool
+}
+
+// A DataSource supplies the nav_closed_ends payload to a Client. Payload may
+// be called concurrently.
+type DataSource interface {
+	Payload(ctx context.Context) (Payload, error)
+}
+
+// WithDataSource makes the Client read its payload from src instead of the
+// GAMCO API. The options configuring HTTP requests, including
+// WithDiskCache, then have no effect; caching and request coalescing still
+// apply.
+func WithDataSource(src DataSource) Option {
+	return func(c *Client) {
+		c.source = src
+	}
+}
+
+// LoadFunds returns every Fund in src's payload, in feed order.
+func LoadFunds(ctx context.Context, src DataSource) ([]Fund, error) {
+	p, err := src.Payload(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = contextErr(ctx, nil); err != nil {
+		return nil, err
+	}
+	return decodeFunds(p.Body)
+}
+
+// NewHTTPSource returns a DataSource reading from the GAMCO API, configured
+// by the same options as NewClient. It is what a Client uses when it is not
+// given WithDataSource.
+func NewHTTPSource(opts ...Option) DataSource {
+	c := NewClient(opts...)
+	return &httpSource{c: c}
+}
+
+// An httpSource reads the payload from the API through its Client.
+type httpSource struct {
+	c *Client
+}
+
+// Payload fetches the payload, going through the Client's retry policy and
+// disk cache.
+func (s *httpSource) Payload(ctx context.Context) (Payload, error) {
+	return s.c.loadPayload(ctx)
+}
+
+// A FileSource reads the payload from a local file, such as an archived
+// response or example.json. Its FetchedAt is when the file was read and its
+// ModTime the file's modification time.
+type FileSource struct {
+	Path string
+}
+
+// Payload reads the file.
+func (s FileSource) Payload(ctx context.Context) (Payload, error) {
+	if err := contextErr(ctx, nil); err != nil {
+		return Payload{}, err
+	}
+
+	fi, err := os.Stat(s.Path)
+	if err != nil {
+		return Payload{}, fmt.Errorf("Reading payload file failed: %w", err)
+	}
+	body, err := ioutil.ReadFile(s.Path)
+	if err != nil {
+		return Payload{}, fmt.Errorf("Reading payload file failed: %w", err)
+	}
+
+	return Payload{Body: body, FetchedAt: time.Now(), ModTime: fi.ModTime()}, nil
+}
+
+// A ReaderSource reads the payload from an io.Reader. The reader is consumed
+// on the first successful call to Payload; later calls return the same body.
+// A call abandoned because its context is done keeps what it read, and the
+// next call resumes reading.
+type ReaderSource struct {
+	r io.Reader
+
+	mu        sync.Mutex
+	body      []byte
+	done      bool
+	fetchedAt time.Time
+	err       error
+}
+
+// NewReaderSource returns a ReaderSource reading from r.
+func NewReaderSource(r io.Reader) *ReaderSource {
+	return &ReaderSource{r: r}
+}
+
+// Payload returns the reader's contents.
+func (s *ReaderSource) Payload(ctx context.Context) (Payload, error) {
+	if err := contextErr(ctx, nil); err != nil {
+		return Payload{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.done {
+		rest, err := ioutil.ReadAll(&contextReader{ctx: ctx, r: s.r})
+		s.body = append(s.body, rest...)
+		if err != nil {
+			// Only a read error is final; a done context is the caller's,
+			// so the next caller carries on reading.
+			if ctxErr := contextErr(ctx, nil); ctxErr != nil {
+				return Payload{}, ctxErr
+			}
+			s.err = fmt.Errorf("Reading payload failed: %w", err)
+		}
+		s.done = true
+		s.fetchedAt = time.Now()
+	}
+	if s.err != nil {
+		return Payload{}, s.err
+	}
+
+	return Payload{Body: s.body, FetchedAt: s.fetchedAt}, nil
+}
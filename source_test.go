@@ -0,0 +1,196 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/jidicula/go-gamco/gamcotest"
+)
+
+func TestDataSources(t *testing.T) {
+	payload := gamcotest.Example()
+	srv := newTestServer(t)
+
+	tests := map[string]struct {
+		src     DataSource
+		want    int
+		wantErr bool
+	}{
+		"http":         {src: NewHTTPSource(WithBaseURL(srv.BaseURL())), want: 53},
+		"file":         {src: FileSource{Path: "gamcotest/example.json"}, want: 53},
+		"reader":       {src: NewReaderSource(bytes.NewReader(payload)), want: 53},
+		"empty reader": {src: NewReaderSource(bytes.NewReader([]byte("[]"))), want: 0},
+		"missing file": {src: FileSource{Path: "missing.json"}, wantErr: true},
+		"bad http":     {src: NewHTTPSource(WithBaseURL(srv.URL+"/nope"), WithRetryPolicy(NoRetry)), wantErr: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := LoadFunds(context.Background(), tt.src)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("%s: got nil error, want error", name)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			if len(got) != tt.want {
+				t.Errorf("%s: got %v Funds, want %v", name, len(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestClientWithDataSource(t *testing.T) {
+	c := NewClient(WithDataSource(FileSource{Path: "gamcotest/example.json"}))
+
+	f, err := c.GetFund("GDV")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if f.Symbol != "GDV" {
+		t.Errorf("got %v, want GDV", f.Symbol)
+	}
+
+	fl, err := c.GetCommonFundList()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if len(fl) != 14 {
+		t.Errorf("got list with %v Funds, want list with 14 Funds", len(fl))
+	}
+}
+
+func TestReaderSourceReusesBody(t *testing.T) {
+	payload := gamcotest.Example()
+	c := NewClient(WithDataSource(NewReaderSource(bytes.NewReader(payload))))
+
+	for i := 0; i < 3; i++ {
+		s, err := c.Refresh()
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		if got := len(s.Funds()); got != 53 {
+			t.Errorf("call %v: got %v Funds, want 53", i, got)
+		}
+	}
+}
+
+func TestDataSourceCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for name, src := range map[string]DataSource{
+		"file":   FileSource{Path: "gamcotest/example.json"},
+		"reader": NewReaderSource(bytes.NewReader([]byte("[]"))),
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := LoadFunds(ctx, src); !errors.Is(err, context.Canceled) {
+				t.Errorf("%s: got %v, want %v", name, err, context.Canceled)
+			}
+		})
+	}
+}
+
+// A slowReader returns its contents a few bytes at a time, pausing before
+// each read.
+type slowReader struct {
+	b     []byte
+	pause time.Duration
+}
+
+func (r *slowReader) Read(p []byte) (int, error) {
+	time.Sleep(r.pause)
+	if len(r.b) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p[:minInt(len(p), 256)], r.b)
+	r.b = r.b[n:]
+	return n, nil
+}
+
+func TestReaderSourceResumesAfterCancel(t *testing.T) {
+	payload := gamcotest.Example()
+	src := NewReaderSource(&slowReader{b: payload, pause: time.Millisecond})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := src.Payload(ctx)
+	var ctxErr *ContextError
+	if !errors.As(err, &ctxErr) {
+		t.Fatalf("deadline: got %v, want *ContextError", err)
+	}
+
+	p, err := src.Payload(context.Background())
+	if err != nil {
+		t.Fatalf("fresh context: got %v", err)
+	}
+	if !bytes.Equal(p.Body, payload) {
+		t.Errorf("fresh context: got %d bytes, want %d", len(p.Body), len(payload))
+	}
+}
+
+func TestReaderSourceKeepsReadError(t *testing.T) {
+	src := NewReaderSource(iotest.ErrReader(errors.New("boom")))
+	for i := 0; i < 2; i++ {
+		if _, err := src.Payload(context.Background()); err == nil {
+			t.Errorf("call %v: got nil error, want error", i)
+		}
+	}
+}
+
+func TestFileSourceCacheTTL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "payload.json")
+	if err := ioutil.WriteFile(path, gamcotest.Example(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := FileSource{Path: path}.Payload(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.ModTime.Equal(modTime) {
+		t.Errorf("got ModTime %v, want %v", p.ModTime, modTime)
+	}
+
+	c := NewClient(WithDataSource(FileSource{Path: path}), WithCacheTTL(time.Hour))
+	first, err := c.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := c.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Error("got a new Snapshot, want the cached one")
+	}
+}
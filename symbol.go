@@ -0,0 +1,146 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// A SecurityKind is the kind of security a symbol denotes.
+type SecurityKind int
+
+// Security kinds. UnknownSecurity is the zero value, for symbols that could
+// not be parsed.
+const (
+	UnknownSecurity SecurityKind = iota
+	Common
+	Preferred
+	Rights
+	ForeignListing
+)
+
+var securityKindNames = [...]string{
+	UnknownSecurity: "unknown",
+	Common:          "common",
+	Preferred:       "preferred",
+	Rights:          "rights",
+	ForeignListing:  "foreign listing",
+}
+
+// String returns k's name, e.g. "preferred".
+func (k SecurityKind) String() string {
+	if k < 0 || int(k) >= len(securityKindNames) {
+		return fmt.Sprintf("SecurityKind(%d)", int(k))
+	}
+	return securityKindNames[k]
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (k SecurityKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// A Security is a parsed Fund symbol.
+type Security struct {
+	// Symbol is the symbol as it was parsed.
+	Symbol string
+	// Base is the ticker of the fund's common shares, e.g. "GAB" for
+	// "GAB PrK".
+	Base string
+	Kind SecurityKind
+	// Series is a preferred share's series letter, e.g. "K" for "GAB PrK".
+	Series string
+	// Exchange is a foreign listing's exchange suffix, e.g. "LN" for
+	// "GMP LN".
+	Exchange string
+}
+
+// String returns s's symbol in one consistent spelling: "GAB", "GABprK",
+// "GUT RT" or "GMP LN".
+func (s Security) String() string {
+	switch s.Kind {
+	case Common:
+		return s.Base
+	case Preferred:
+		return s.Base + "pr" + s.Series
+	case Rights:
+		return s.Base + " RT"
+	case ForeignListing:
+		return s.Base + " " + s.Exchange
+	}
+	return s.Symbol
+}
+
+var (
+	// tickerPattern matches a ticker with an optional preferred series
+	// glued on, e.g. "GAB", "GABprH" or "GNTPrA". An all-caps "PR" is taken
+	// as part of the ticker.
+	tickerPattern = regexp.MustCompile(`^([A-Z]{1,5}?)(?:[pP]r([A-Z]))?$`)
+	// seriesPattern matches a preferred series given as a separate word,
+	// e.g. the "PrK" of "GAB PrK".
+	seriesPattern = regexp.MustCompile(`^[pP][rR]([A-Z])$`)
+	// exchangePattern matches an exchange suffix, e.g. the "LN" of
+	// "GMP LN".
+	exchangePattern = regexp.MustCompile(`^[A-Z]{2}$`)
+)
+
+// ParseSymbol parses a symbol as the API spells it. The API writes
+// preferred series as "pr", "Pr" or " Pr" followed by the series letter,
+// rights with an " RT" suffix, and listings on foreign exchanges with the
+// exchange's two-letter suffix.
+func ParseSymbol(symbol string) (Security, error) {
+	s := Security{Symbol: symbol}
+	words := strings.Fields(symbol)
+	if len(words) == 0 || len(words) > 2 {
+		return s, fmt.Errorf("Invalid symbol %q", symbol)
+	}
+	m := tickerPattern.FindStringSubmatch(words[0])
+	if m == nil {
+		return s, fmt.Errorf("Invalid symbol %q", symbol)
+	}
+	s.Base, s.Series = m[1], m[2]
+
+	switch {
+	case len(words) == 1 && s.Series == "":
+		s.Kind = Common
+	case len(words) == 1:
+		s.Kind = Preferred
+	case s.Series != "":
+		// A series and a suffix, e.g. "GABprH LN", is not a spelling the
+		// API uses.
+		return Security{Symbol: symbol}, fmt.Errorf("Invalid symbol %q", symbol)
+	case words[1] == "RT":
+		s.Kind = Rights
+	case seriesPattern.MatchString(words[1]):
+		s.Kind = Preferred
+		s.Series = seriesPattern.FindStringSubmatch(words[1])[1]
+	case exchangePattern.MatchString(words[1]):
+		s.Kind = ForeignListing
+		s.Exchange = words[1]
+	default:
+		return Security{Symbol: symbol}, fmt.Errorf("Invalid symbol %q", symbol)
+	}
+
+	return s, nil
+}
+
+// Security parses f's symbol. If the symbol cannot be parsed, the result's
+// Kind is UnknownSecurity.
+func (f Fund) Security() Security {
+	s, _ := ParseSymbol(f.Symbol)
+	return s
+}
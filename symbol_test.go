@@ -0,0 +1,188 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jidicula/go-gamco/gamcotest"
+)
+
+func TestParseSymbol(t *testing.T) {
+	common := func(base string) Security {
+		return Security{Symbol: base, Base: base, Kind: Common}
+	}
+	preferred := func(symbol, base, series string) Security {
+		return Security{Symbol: symbol, Base: base, Kind: Preferred, Series: series}
+	}
+
+	// Every symbol in example.json, plus spellings the feed might use.
+	tests := map[string]struct {
+		want    Security
+		wantErr bool
+	}{
+		"GAB":     {want: common("GAB")},
+		"BCV":     {want: common("BCV")},
+		"ECF":     {want: common("ECF")},
+		"GCV":     {want: common("GCV")},
+		"GDL":     {want: common("GDL")},
+		"GDV":     {want: common("GDV")},
+		"GGN":     {want: common("GGN")},
+		"GGO":     {want: common("GGO")},
+		"GGT":     {want: common("GGT")},
+		"GGZ":     {want: common("GGZ")},
+		"GLU":     {want: common("GLU")},
+		"GNT":     {want: common("GNT")},
+		"GRX":     {want: common("GRX")},
+		"GUT":     {want: common("GUT")},
+		"BCVprA":  {want: preferred("BCVprA", "BCV", "A")},
+		"ECFprA":  {want: preferred("ECFprA", "ECF", "A")},
+		"GABprG":  {want: preferred("GABprG", "GAB", "G")},
+		"GABprH":  {want: preferred("GABprH", "GAB", "H")},
+		"GABprJ":  {want: preferred("GABprJ", "GAB", "J")},
+		"GAB PrK": {want: preferred("GAB PrK", "GAB", "K")},
+		"GDLPrC":  {want: preferred("GDLPrC", "GDL", "C")},
+		"GDVprG":  {want: preferred("GDVprG", "GDV", "G")},
+		"GGNprB":  {want: preferred("GGNprB", "GGN", "B")},
+		"GGOprA":  {want: preferred("GGOprA", "GGO", "A")},
+		"GGTprE":  {want: preferred("GGTprE", "GGT", "E")},
+		"GGZprA":  {want: preferred("GGZprA", "GGZ", "A")},
+		"GLUprA":  {want: preferred("GLUprA", "GLU", "A")},
+		"GNTPrA":  {want: preferred("GNTPrA", "GNT", "A")},
+		"GRXprC":  {want: preferred("GRXprC", "GRX", "C")},
+		"GUTprA":  {want: preferred("GUTprA", "GUT", "A")},
+		"GUTprC":  {want: preferred("GUTprC", "GUT", "C")},
+		"GUT RT":  {want: Security{Symbol: "GUT RT", Base: "GUT", Kind: Rights}},
+		"GMP LN":  {want: Security{Symbol: "GMP LN", Base: "GMP", Kind: ForeignListing, Exchange: "LN"}},
+		"GVP LN":  {want: Security{Symbol: "GVP LN", Base: "GVP", Kind: ForeignListing, Exchange: "LN"}},
+
+		"GAB prK":    {want: preferred("GAB prK", "GAB", "K")},
+		"GAB PRK":    {want: preferred("GAB PRK", "GAB", "K")},
+		" GUT ":      {want: Security{Symbol: " GUT ", Base: "GUT", Kind: Common}},
+		"GAB  PrK":   {want: preferred("GAB  PrK", "GAB", "K")},
+		"A":          {want: common("A")},
+		"SPRX":       {want: common("SPRX")},
+		"":           {wantErr: true},
+		"   ":        {wantErr: true},
+		"gab":        {wantErr: true},
+		"GABprk":     {wantErr: true},
+		"GABprHJ":    {wantErr: true},
+		"TOOLONG":    {wantErr: true},
+		"GAB Pr":     {wantErr: true},
+		"GAB XYZ":    {wantErr: true},
+		"GAB RT LN":  {wantErr: true},
+		"GABprH LN":  {wantErr: true},
+		"GAB-PrK":    {wantErr: true},
+		"GABpr":      {wantErr: true},
+		"prA":        {wantErr: true},
+		"GAB PrK RT": {wantErr: true},
+	}
+
+	for symbol, tt := range tests {
+		t.Run(symbol, func(t *testing.T) {
+			got, err := ParseSymbol(symbol)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("%q: got %+v, want error", symbol, got)
+				}
+				if got.Kind != UnknownSecurity || got.Symbol != symbol {
+					t.Errorf("%q: got %+v, want unknown Security", symbol, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%q: %v", symbol, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%q: got %+v, want %+v", symbol, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSecurityString(t *testing.T) {
+	tests := map[string]string{
+		"GAB":     "GAB",
+		"GAB PrK": "GABprK",
+		"GNTPrA":  "GNTprA",
+		"GDLPrC":  "GDLprC",
+		"GUT RT":  "GUT RT",
+		"GMP LN":  "GMP LN",
+	}
+
+	for symbol, want := range tests {
+		s, err := ParseSymbol(symbol)
+		if err != nil {
+			t.Fatalf("%q: %v", symbol, err)
+		}
+		if got := s.String(); got != want {
+			t.Errorf("%q: got %q, want %q", symbol, got, want)
+		}
+	}
+	if got := (Security{Symbol: "???"}).String(); got != "???" {
+		t.Errorf("unknown Security: got %q, want %q", got, "???")
+	}
+}
+
+func TestSecurityKindString(t *testing.T) {
+	tests := map[SecurityKind]string{
+		UnknownSecurity:  "unknown",
+		Common:           "common",
+		Preferred:        "preferred",
+		Rights:           "rights",
+		ForeignListing:   "foreign listing",
+		SecurityKind(99): "SecurityKind(99)",
+	}
+
+	for k, want := range tests {
+		if got := k.String(); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+}
+
+// TestFundSecurityExample parses every symbol in example.json.
+func TestFundSecurityExample(t *testing.T) {
+	fl, err := decodeFunds(gamcotest.Example())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	kinds := map[SecurityKind]int{}
+	for _, f := range fl {
+		s := f.Security()
+		kinds[s.Kind]++
+		if f.Symbol == "" {
+			continue
+		}
+		if s.Kind == UnknownSecurity {
+			t.Errorf("fund %d: could not parse %q", f.ID, f.Symbol)
+			continue
+		}
+		again, err := ParseSymbol(s.String())
+		if err != nil {
+			t.Errorf("fund %d: %v", f.ID, err)
+		}
+		again.Symbol = s.Symbol
+		if again != s {
+			t.Errorf("fund %d: got %+v from %q, want %+v", f.ID, again, s.String(), s)
+		}
+	}
+	want := map[SecurityKind]int{UnknownSecurity: 3, Common: 28, Preferred: 17, Rights: 1, ForeignListing: 4}
+	if !reflect.DeepEqual(kinds, want) {
+		t.Errorf("got kinds %v, want %v", kinds, want)
+	}
+}
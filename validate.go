@@ -0,0 +1,254 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import (
+	"fmt"
+	"time"
+)
+
+// A Severity ranks a Finding.
+type Severity int
+
+// Severities, from least to most severe.
+const (
+	// SeverityInfo notes something unusual that is not wrong, such as a
+	// missing CUSIP.
+	SeverityInfo Severity = iota
+	// SeverityWarning marks a value that is suspect but may be right, such
+	// as an implausibly large return.
+	SeverityWarning
+	// SeverityError marks a value that cannot be right, such as a Change
+	// that is not NAV - PriorNAV. Records with errors should be quarantined.
+	SeverityError
+)
+
+var severityNames = [...]string{
+	SeverityInfo:    "info",
+	SeverityWarning: "warning",
+	SeverityError:   "error",
+}
+
+// String returns s's name, e.g. "warning".
+func (s Severity) String() string {
+	if s < 0 || int(s) >= len(severityNames) {
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+	return severityNames[s]
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// A Finding is a data-quality problem with one Fund.
+type Finding struct {
+	FundID int
+	Symbol string
+	// Field is the upstream name of the field at fault, e.g. "pct_change".
+	Field    string
+	Severity Severity
+	Message  string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s of %s (fund %d): %s", f.Severity, f.Field, f.Symbol, f.FundID, f.Message)
+}
+
+// A ValidateOption configures validation.
+type ValidateOption func(*validateOptions)
+
+type validateOptions struct {
+	now          time.Time
+	pctTolerance Decimal
+}
+
+// DefaultPctChangeTolerance is how far PctChange may stray from
+// Change / PriorNAV: the API rounds it to six places.
+var DefaultPctChangeTolerance = NewDecimal(1, 6)
+
+// WithValidationTime validates as of t rather than now, e.g. when
+// validating an archived payload. NAVDates after t are reported.
+func WithValidationTime(t time.Time) ValidateOption {
+	return func(o *validateOptions) {
+		o.now = t
+	}
+}
+
+// WithPctChangeTolerance sets how far PctChange may stray from
+// Change / PriorNAV; the default is DefaultPctChangeTolerance.
+func WithPctChangeTolerance(d Decimal) ValidateOption {
+	return func(o *validateOptions) {
+		o.pctTolerance = d
+	}
+}
+
+// Plausible return bounds, as fractions. Returns below -1 are impossible;
+// above these bounds they are implausible.
+const (
+	maxPlausibleReturn           = 10.0
+	maxPlausibleAnnualizedReturn = 1.0
+)
+
+// documentFields are the upstream names of the document link fields, by
+// DocumentKind.
+var documentFields = [...]string{
+	AnnualReportDoc:     "annual_report",
+	SemiAnnualReportDoc: "semi_annual_report",
+	QuarterlyReportDoc:  "quarterly_report",
+	ProspectusDoc:       "prospectus",
+	SAIDoc:              "sai",
+	SOIDoc:              "soi",
+	FactsheetDoc:        "factsheet",
+	CommentaryDoc:       "commentary",
+}
+
+// Validate checks f for internal consistency and returns its problems in
+// field order, or nil if it found none. It checks that:
+//
+//   - Change is NAV - PriorNAV, exactly;
+//   - PctChange is Change / PriorNAV, within a tolerance;
+//   - Cusip matches SecurityID, and both have valid check digits;
+//   - NAVDate is present and not in the future;
+//   - InceptionDate is before NAVDate;
+//   - returns are possible (not below -100%) and plausible (annualized
+//     returns at most 100%, others at most 1000%);
+//   - document links are well-formed http(s) URLs.
+func (f Fund) Validate(opts ...ValidateOption) []Finding {
+	o := validateOptions{pctTolerance: DefaultPctChangeTolerance}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.now.IsZero() {
+		o.now = time.Now()
+	}
+
+	var findings []Finding
+	report := func(field string, s Severity, format string, args ...interface{}) {
+		findings = append(findings, Finding{
+			FundID:   f.ID,
+			Symbol:   f.Symbol,
+			Field:    field,
+			Severity: s,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	f.validateIdentifiers(report)
+	f.validatePrices(o, report)
+	f.validateDates(o, report)
+	f.validateReturns(report)
+	for _, d := range f.Documents() {
+		if d.Err != nil {
+			report(documentFields[d.Kind], SeverityWarning, "%v", d.Err)
+		}
+	}
+
+	return findings
+}
+
+// ValidateAll validates every Fund in fl, returning their findings in fund
+// order.
+func ValidateAll(fl []Fund, opts ...ValidateOption) []Finding {
+	var findings []Finding
+	for _, f := range fl {
+		findings = append(findings, f.Validate(opts...)...)
+	}
+	return findings
+}
+
+type reportFunc func(field string, s Severity, format string, args ...interface{})
+
+func (f Fund) validateIdentifiers(report reportFunc) {
+	cusip, securityID := normalizeID(f.Cusip), normalizeID(f.SecurityID)
+	switch {
+	case securityID == "":
+		report("security_id", SeverityError, "Missing security ID")
+	case len(securityID) == 9:
+		if _, err := ParseCUSIP(securityID); err != nil {
+			report("security_id", SeverityError, "%v", err)
+		}
+	case len(securityID) == 7:
+		if _, err := ParseSEDOL(securityID); err != nil {
+			report("security_id", SeverityError, "%v", err)
+		}
+	default:
+		report("security_id", SeverityWarning, "%q is neither a CUSIP nor a SEDOL", f.SecurityID)
+	}
+
+	switch {
+	case cusip == "":
+		report("cusip", SeverityInfo, "Missing CUSIP")
+	case cusip != securityID:
+		report("cusip", SeverityWarning, "CUSIP %q does not match security ID %q", f.Cusip, f.SecurityID)
+		if _, err := ParseCUSIP(cusip); len(cusip) != 9 || err != nil {
+			report("cusip", SeverityError, "Invalid CUSIP %q", f.Cusip)
+		}
+	}
+}
+
+func (f Fund) validatePrices(o validateOptions, report reportFunc) {
+	if !f.NAV.Valid() {
+		report("price", SeverityWarning, "Missing NAV")
+	}
+	if !f.PriorNAV.Valid() {
+		report("prior_price", SeverityWarning, "Missing prior NAV")
+	}
+	if !f.NAV.Valid() || !f.PriorNAV.Valid() || !f.Change.Valid() {
+		return
+	}
+
+	if want := f.NAV.Sub(f.PriorNAV); !f.Change.Equal(want) {
+		report("change", SeverityError, "Change %s is not NAV - prior NAV = %s", f.Change, want)
+	}
+	if !f.PctChange.Valid() || f.PriorNAV.IsZero() {
+		return
+	}
+	want := f.Change.Div(f.PriorNAV, f.PctChange.Scale()+2)
+	if f.PctChange.Sub(want).Abs().Cmp(o.pctTolerance) > 0 {
+		report("pct_change", SeverityError, "Percent change %s is not change / prior NAV = %s", f.PctChange, want)
+	}
+}
+
+func (f Fund) validateDates(o validateOptions, report reportFunc) {
+	switch {
+	case f.NAVDate.IsZero():
+		report("pricedate", SeverityWarning, "Missing NAV date")
+		return
+	case f.NAVDate.After(o.now):
+		report("pricedate", SeverityError, "NAV date %s is in the future", f.NAVDate.Format("2006-01-02"))
+	}
+
+	if !f.InceptionDate.IsZero() && !f.InceptionDate.Before(f.NAVDate) {
+		report("inception_date", SeverityError, "Inception date %s is not before NAV date %s",
+			f.InceptionDate.Format("2006-01-02"), f.NAVDate.Format("2006-01-02"))
+	}
+}
+
+func (f Fund) validateReturns(report reportFunc) {
+	f.Returns().Each(func(p Period, b Basis, v float64) {
+		max := maxPlausibleReturn
+		if p >= ThreeYear {
+			max = maxPlausibleAnnualizedReturn
+		}
+		switch {
+		case v < -1:
+			report(returnFields[p][b], SeverityError, "%s %s return %g is below -100%%", p, b, v)
+		case v > max:
+			report(returnFields[p][b], SeverityWarning, "%s %s return %g is implausibly high", p, b, v)
+		}
+	})
+}
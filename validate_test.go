@@ -0,0 +1,190 @@
+// github.com/jidicula/go-gamco provides an unofficial API wrapper for GAMCO's
+// Closed-End Funds API.
+// Copyright (C) 2021  Johanan Idicula
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package gamco
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jidicula/go-gamco/gamcotest"
+)
+
+func TestFundValidate(t *testing.T) {
+	now := time.Date(2021, 4, 2, 12, 0, 0, 0, time.UTC)
+	valid := func() Fund {
+		return Fund{
+			ID:            502,
+			Symbol:        "GAB",
+			SecurityID:    "362397101",
+			Cusip:         "362397101",
+			NAVDate:       time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC),
+			InceptionDate: time.Date(1986, 8, 21, 0, 0, 0, 0, time.UTC),
+			NAV:           MustParseDecimal("6.87"),
+			PriorNAV:      MustParseDecimal("6.82"),
+			Change:        MustParseDecimal("0.05"),
+			PctChange:     MustParseDecimal("0.007331"),
+			OneYrReturn:   0.72,
+			InceptAvg:     0.047,
+			AnnualReport:  "https://gab-annual-reports.s3.us-east-2.amazonaws.com/GABFundWebReady12312020.pdf",
+		}
+	}
+
+	tests := map[string]struct {
+		edit func(*Fund)
+		want map[string]Severity
+	}{
+		"valid": {
+			edit: func(f *Fund) {},
+		},
+		"change": {
+			edit: func(f *Fund) { f.Change = MustParseDecimal("0.04") },
+			want: map[string]Severity{"change": SeverityError, "pct_change": SeverityError},
+		},
+		"change scale": {
+			edit: func(f *Fund) { f.Change = MustParseDecimal("0.050") },
+		},
+		"pct_change": {
+			edit: func(f *Fund) { f.PctChange = MustParseDecimal("0.0074") },
+			want: map[string]Severity{"pct_change": SeverityError},
+		},
+		"pct_change within tolerance": {
+			edit: func(f *Fund) { f.PctChange = MustParseDecimal("0.007332") },
+		},
+		"pct_change zero prior": {
+			edit: func(f *Fund) {
+				f.NAV, f.PriorNAV, f.Change = MustParseDecimal("0.05"), MustParseDecimal("0"), MustParseDecimal("0.05")
+			},
+		},
+		"missing prices": {
+			edit: func(f *Fund) { f.NAV, f.PriorNAV = Decimal{}, Decimal{} },
+			want: map[string]Severity{"price": SeverityWarning, "prior_price": SeverityWarning},
+		},
+		"cusip mismatch": {
+			edit: func(f *Fund) { f.Cusip = "36242H104" },
+			want: map[string]Severity{"cusip": SeverityWarning},
+		},
+		"cusip check digit": {
+			edit: func(f *Fund) { f.Cusip, f.SecurityID = "362397102", "362397102" },
+			want: map[string]Severity{"security_id": SeverityError},
+		},
+		"mismatched invalid cusip": {
+			edit: func(f *Fund) { f.Cusip = "362397102" },
+			want: map[string]Severity{"cusip": SeverityError},
+		},
+		"missing cusip": {
+			edit: func(f *Fund) { f.Cusip = "-" },
+			want: map[string]Severity{"cusip": SeverityInfo},
+		},
+		"sedol": {
+			edit: func(f *Fund) { f.Cusip, f.SecurityID = "BD8P074", "BD8P074" },
+		},
+		"sedol check digit": {
+			edit: func(f *Fund) { f.Cusip, f.SecurityID = "BD8P075", "BD8P075" },
+			want: map[string]Severity{"security_id": SeverityError},
+		},
+		"unknown identifier": {
+			edit: func(f *Fund) { f.Cusip, f.SecurityID = "GAB", "GAB" },
+			want: map[string]Severity{"security_id": SeverityWarning},
+		},
+		"future NAV date": {
+			edit: func(f *Fund) { f.NAVDate = now.AddDate(0, 0, 1) },
+			want: map[string]Severity{"pricedate": SeverityError},
+		},
+		"missing NAV date": {
+			edit: func(f *Fund) { f.NAVDate = time.Time{} },
+			want: map[string]Severity{"pricedate": SeverityWarning},
+		},
+		"inception after NAV date": {
+			edit: func(f *Fund) { f.InceptionDate = f.NAVDate },
+			want: map[string]Severity{"inception_date": SeverityError},
+		},
+		"impossible return": {
+			edit: func(f *Fund) { f.YtdReturnMonthly = -1.5 },
+			want: map[string]Severity{"ytd_return_monthly": SeverityError},
+		},
+		"implausible annualized return": {
+			edit: func(f *Fund) { f.InceptAvg = 1314250812077360.0 },
+			want: map[string]Severity{"incept_avg": SeverityWarning},
+		},
+		"large one-year return": {
+			edit: func(f *Fund) { f.OneYrReturn = 2.5 },
+		},
+		"bad document link": {
+			edit: func(f *Fund) { f.Sai = "/-113_sai.pdf" },
+			want: map[string]Severity{"sai": SeverityWarning},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			f := valid()
+			tt.edit(&f)
+			got := map[string]Severity{}
+			for _, finding := range f.Validate(WithValidationTime(now)) {
+				if finding.FundID != f.ID || finding.Symbol != f.Symbol {
+					t.Errorf("%s: finding %v not attributed to fund %d", name, finding, f.ID)
+				}
+				got[finding.Field] = finding.Severity
+			}
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s: got %v, want %v", name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateAll(t *testing.T) {
+	fl, err := decodeFunds(gamcotest.Example())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2021, 4, 2, 12, 0, 0, 0, time.UTC)
+	var got []Finding
+	for _, f := range ValidateAll(fl, WithValidationTime(now)) {
+		if f.Severity > SeverityInfo {
+			got = append(got, f)
+		}
+	}
+	want := []Finding{{
+		FundID:   511,
+		Symbol:   "GGOprA",
+		Field:    "incept_avg",
+		Severity: SeverityWarning,
+		Message:  "inception daily return 1.31425081207736e+15 is implausibly high",
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	past := ValidateAll(fl, WithValidationTime(time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)))
+	if n := countSeverity(past, "pricedate", SeverityError); n != len(fl) {
+		t.Errorf("future NAV dates: got %d, want %d", n, len(fl))
+	}
+}
+
+func countSeverity(findings []Finding, field string, s Severity) int {
+	n := 0
+	for _, f := range findings {
+		if f.Field == field && f.Severity == s {
+			n++
+		}
+	}
+	return n
+}